@@ -0,0 +1,4 @@
+-- Мягкое удаление: обычный DELETE только помечает задачу, безвозвратно удаляет администратор
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_deleted_at ON tasks(deleted_at);
@@ -0,0 +1,3 @@
+-- Откуда открыта сессия: показывается пользователю в списке активных сессий
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS ip TEXT NOT NULL DEFAULT '';
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS user_agent TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+-- Срок выполнения задачи; NULL - срок не задан
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS due_date TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,13 @@
+-- Владелец задачи - имя пользователя (subject), проверенное auth service.
+-- Существующие задачи передаются владельцу по умолчанию: параметр tasks.default_owner
+-- (например, PGOPTIONS='-c tasks.default_owner=alice' psql -f ... или
+-- ALTER DATABASE tasks_db SET tasks.default_owner = 'alice'), иначе 'student' - демо-пользователь auth.
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS owner_id TEXT;
+
+UPDATE tasks
+SET owner_id = COALESCE(NULLIF(current_setting('tasks.default_owner', true), ''), 'student')
+WHERE owner_id IS NULL;
+
+ALTER TABLE tasks ALTER COLUMN owner_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_owner_created ON tasks(owner_id, created_at DESC);
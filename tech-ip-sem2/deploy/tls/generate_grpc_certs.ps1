@@ -0,0 +1,49 @@
+$env:Path += ";C:\Program Files\OpenSSL-Win64\bin"
+
+# generate_grpc_certs.ps1
+# CA и сертификаты для gRPC между tasks и auth (TLS и mTLS):
+#   auth:  AUTH_GRPC_TLS_CERT=grpc/server.pem AUTH_GRPC_TLS_KEY=grpc/server.key AUTH_GRPC_TLS_CLIENT_CA=grpc/ca.pem
+#   tasks: AUTH_GRPC_CA_FILE=grpc/ca.pem AUTH_GRPC_CLIENT_CERT=grpc/client.pem AUTH_GRPC_CLIENT_KEY=grpc/client.key
+#   RevokeSessions разрешён клиентам из AUTH_GRPC_ADMIN_CLIENTS (например, AUTH_GRPC_ADMIN_CLIENTS=tasks)
+Write-Host "Generating CA and gRPC certificates..." -ForegroundColor Green
+
+$certDir = Join-Path (Split-Path -Parent $MyInvocation.MyCommand.Path) "grpc"
+New-Item -ItemType Directory -Force -Path $certDir | Out-Null
+
+# Проверяем наличие openssl
+$openssl = Get-Command openssl -ErrorAction SilentlyContinue
+if (-not $openssl) {
+    Write-Host "OpenSSL not found. Please install OpenSSL or use WSL." -ForegroundColor Red
+    exit 1
+}
+
+$ca = Join-Path $certDir "ca"
+$server = Join-Path $certDir "server"
+$client = Join-Path $certDir "client"
+
+# Корневой CA
+openssl req -x509 -newkey rsa:2048 -nodes `
+    -keyout "$ca.key" `
+    -out "$ca.pem" `
+    -days 365 `
+    -subj "/CN=tasks-internal-ca"
+
+# Сертификат auth service (имена, по которым к нему обращается tasks)
+Set-Content -Path "$server.ext" -Value "subjectAltName=DNS:localhost,DNS:auth,IP:127.0.0.1`nextendedKeyUsage=serverAuth"
+openssl req -newkey rsa:2048 -nodes -keyout "$server.key" -out "$server.csr" -subj "/CN=auth"
+openssl x509 -req -in "$server.csr" -CA "$ca.pem" -CAkey "$ca.key" -CAcreateserial `
+    -out "$server.pem" -days 365 -extfile "$server.ext"
+
+# Клиентский сертификат tasks service (для mTLS)
+Set-Content -Path "$client.ext" -Value "extendedKeyUsage=clientAuth"
+openssl req -newkey rsa:2048 -nodes -keyout "$client.key" -out "$client.csr" -subj "/CN=tasks"
+openssl x509 -req -in "$client.csr" -CA "$ca.pem" -CAkey "$ca.key" -CAcreateserial `
+    -out "$client.pem" -days 365 -extfile "$client.ext"
+
+Remove-Item "$server.csr", "$client.csr", "$server.ext", "$client.ext"
+
+Write-Host "Certificates generated in $certDir" -ForegroundColor Green
+Write-Host "  CA:     ca.pem"
+Write-Host "  Server: server.pem / server.key"
+Write-Host "  Client: client.pem / client.key"
+Write-Host "Certificates are re-read on change, rotate them in place without restarting services."
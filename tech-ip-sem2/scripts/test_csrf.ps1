@@ -5,17 +5,12 @@ $authUrl = "http://localhost:8081"
 Write-Host "=== Testing CSRF Protection Demo (Simple Mode) ===" -ForegroundColor Green
 Write-Host ""
 
-# Фиксированный CSRF токен (как в коде Auth service)
-$csrfToken = "demo-csrf-456"
-
-Write-Host "Using fixed CSRF token: $csrfToken" -ForegroundColor Cyan
-Write-Host ""
-
 # 1. Логин (просто показываем, что cookies устанавливаются)
+# Пользователь создаётся при старте auth из AUTH_SEED_USERNAME / AUTH_SEED_PASSWORD
 Write-Host "1. Logging in..." -ForegroundColor Yellow
 $loginBody = @{
-    username = "student"
-    password = "student"
+    username = $env:AUTH_SEED_USERNAME
+    password = $env:AUTH_SEED_PASSWORD
 } | ConvertTo-Json
 
 $response = curl.exe -k -s -i -X POST "$authUrl/v1/auth/login" `
@@ -27,6 +22,11 @@ Write-Host "Login response headers:" -ForegroundColor Cyan
 $response | Select-String "Set-Cookie"
 Write-Host ""
 
+# CSRF токен привязан к сессии (HMAC от session_id), берём его из cookie, выданной при логине
+$csrfToken = (Get-Content cookies.txt | Select-String "csrf_token" | ForEach-Object { ($_.Line -split "`t")[-1] }) | Select-Object -Last 1
+Write-Host "Using session-bound CSRF token: $csrfToken" -ForegroundColor Cyan
+Write-Host ""
+
 # 2. Попытка создать задачу без CSRF заголовка (должно быть 403)
 Write-Host "2. Creating task WITHOUT CSRF header (should be 403)..." -ForegroundColor Yellow
 $taskBody = @{
@@ -1,72 +1,340 @@
 package main
 
 import (
+	"context"
+	"math"
 	"net"
 	"net/http"
-	"os"
-	"os/signal"
-	"syscall"
+	"strings"
+	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/config"
 	grp "github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/grpc"
 	httpHandler "github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/http"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/mailer"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/metrics"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/oidc"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/repository"
 	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/service"
 	pb "github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/proto/auth"
+	healthcheck "github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/shared/health"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/shared/interceptors"
 	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/shared/logger"
 	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/shared/middleware"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/shared/server"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/shared/tlsconfig"
 )
 
 func main() {
 	logrusLogger := logger.Init("auth")
-	service.Logger = logrusLogger // для доступа из http-хендлера
 
-	// Запуск HTTP сервера для логина (порт 8081)
-	go func() {
-		httpPort := os.Getenv("AUTH_HTTP_PORT")
-		if httpPort == "" {
-			httpPort = "8081"
+	cfg, err := config.Load()
+	if err != nil {
+		logrusLogger.WithError(err).Fatal("failed to load config")
+	}
+
+	if cfg.CSRFSecret == config.DefaultCSRFSecret {
+		logrusLogger.Warn("CSRF_SECRET is not set, using insecure development secret")
+	}
+
+	// Проверки готовности для /readyz и gRPC health
+	health := healthcheck.New(logrusLogger)
+
+	// Серверы, остановка по сигналу и освобождение ресурсов
+	runner := server.New(logrusLogger, cfg.ShutdownTimeout)
+	runner.OnStop(health.SetStopping)
+
+	// Инициализация хранилищ пользователей, сессий и токенов (refresh, сброс пароля, персональные)
+	var (
+		users          repository.UserRepository
+		sessions       repository.SessionRepository
+		refreshTokens  repository.RefreshTokenRepository
+		passwordResets repository.PasswordResetRepository
+		accessTokens   repository.AccessTokenRepository
+		identities     repository.IdentityRepository
+		auditEvents    repository.AuditRepository
+	)
+	switch cfg.DB.Driver {
+	case "postgres":
+		db, err := repository.OpenPostgres(cfg.DB.DSN())
+		if err != nil {
+			logrusLogger.WithError(err).Fatal("failed to connect to database")
 		}
+		runner.Close("database", db.Close)
+		health.AddCheck("database", db.PingContext)
+		users = repository.NewPostgresUserRepository(db)
+		sessions = repository.NewPostgresSessionRepository(db)
+		refreshTokens = repository.NewPostgresRefreshTokenRepository(db)
+		passwordResets = repository.NewPostgresPasswordResetRepository(db)
+		accessTokens = repository.NewPostgresAccessTokenRepository(db)
+		identities = repository.NewPostgresIdentityRepository(db)
+		auditEvents = repository.NewPostgresAuditRepository(db)
+	case "memory":
+		users = repository.NewMemoryUserRepository()
+		sessions = repository.NewMemorySessionRepository()
+		refreshTokens = repository.NewMemoryRefreshTokenRepository()
+		passwordResets = repository.NewMemoryPasswordResetRepository()
+		accessTokens = repository.NewMemoryAccessTokenRepository()
+		identities = repository.NewMemoryIdentityRepository()
+		auditEvents = repository.NewMemoryAuditRepository()
+	default:
+		logrusLogger.Fatal("unsupported database driver: " + cfg.DB.Driver)
+	}
+
+	// Инициализация сервиса
+	// Ключи подписи JWT (генерируются при старте, ротируются по расписанию)
+	keys, err := service.NewKeyManager(cfg.JWTRotationPeriod, cfg.AccessTokenTTL)
+	if err != nil {
+		logrusLogger.WithError(err).Fatal("failed to generate signing key")
+	}
+
+	// Защита от перебора паролей (счётчики попыток в памяти)
+	guard := service.NewLoginGuard(repository.NewMemoryLoginAttemptStore(), service.LoginGuardConfig{
+		MaxAttemptsPerUsername: cfg.LoginMaxAttempts,
+		MaxAttemptsPerIP:       cfg.LoginMaxAttemptsPerIP,
+		BaseDelay:              cfg.LoginBaseDelay,
+		MaxDelay:               cfg.LoginMaxLockout,
+		Window:                 cfg.LoginAttemptWindow,
+	})
 
-		mux := http.NewServeMux()
-		mux.HandleFunc("POST /v1/auth/login", httpHandler.LoginHandler)
+	// Отправка писем (сброс пароля)
+	var mail mailer.Mailer
+	switch cfg.Mail.Driver {
+	case "smtp":
+		mail = mailer.NewSMTPMailer(mailer.SMTPConfig{
+			Host:     cfg.Mail.SMTPHost,
+			Port:     cfg.Mail.SMTPPort,
+			Username: cfg.Mail.SMTPUser,
+			Password: cfg.Mail.SMTPPass,
+			From:     cfg.Mail.From,
+		})
+	case "log":
+		mail, err = mailer.NewFileMailer(cfg.Mail.File, cfg.Mail.From)
+		if err != nil {
+			logrusLogger.WithError(err).Fatal("failed to open mail file")
+		}
+	default:
+		logrusLogger.Fatal("unsupported mail driver: " + cfg.Mail.Driver)
+	}
 
-		handler := middleware.RequestIDMiddleware(mux)
-		handler = middleware.LoggingMiddleware(handler)
+	authService := service.NewAuthService(
+		service.Stores{
+			Users:          users,
+			Sessions:       sessions,
+			RefreshTokens:  refreshTokens,
+			MFAChallenges:  repository.NewMemoryMFAChallengeStore(),
+			PasswordResets: passwordResets,
+			AccessTokens:   accessTokens,
+			OIDCLogins:     repository.NewMemoryOIDCLoginStore(),
+			Identities:     identities,
+		},
+		keys, guard, mail,
+		service.NewAuditor(auditEvents, logrusLogger),
+		service.Config{
+			Session: service.SessionConfig{
+				TTL:         cfg.SessionTTL,
+				MaxLifetime: cfg.SessionMaxLifetime,
+				CSRFSecret:  []byte(cfg.CSRFSecret),
+			},
+			Token: service.TokenConfig{
+				Issuer:     cfg.JWTIssuer,
+				AccessTTL:  cfg.AccessTokenTTL,
+				RefreshTTL: cfg.RefreshTokenTTL,
+			},
+			MFA: service.MFAConfig{
+				Issuer:               cfg.TOTPIssuer,
+				ChallengeTTL:         cfg.MFAChallengeTTL,
+				MaxChallengeAttempts: 5,
+			},
+			PasswordReset: service.PasswordResetConfig{
+				TTL: cfg.PasswordResetTTL,
+				URL: cfg.PasswordResetURL,
+			},
+		},
+	)
 
-		addr := ":" + httpPort
-		logrusLogger.WithField("port", httpPort).Info("Auth HTTP server starting")
-		if err := http.ListenAndServe(addr, handler); err != nil {
-			logrusLogger.WithError(err).Fatal("HTTP server failed")
+	// Вход через внешнего OIDC-провайдера (SSO)
+	if cfg.OIDCIssuer != "" {
+		if cfg.OIDCClientID == "" {
+			logrusLogger.Fatal("AUTH_OIDC_CLIENT_ID is required when AUTH_OIDC_ISSUER is set")
 		}
-	}()
+		authService.UseOIDC(oidc.NewProvider(oidc.Config{
+			IssuerURL:    cfg.OIDCIssuer,
+			ClientID:     cfg.OIDCClientID,
+			ClientSecret: cfg.OIDCClientSecret,
+			RedirectURL:  cfg.OIDCRedirectURL,
+			Scopes:       strings.Fields(cfg.OIDCScopes),
+		}, nil))
+		logrusLogger.WithField("issuer", cfg.OIDCIssuer).Info("OIDC login enabled")
+	}
 
-	// Запуск gRPC сервера для Verify (порт 50051)
-	grpcPort := os.Getenv("AUTH_GRPC_PORT")
-	if grpcPort == "" {
-		grpcPort = "50051"
+	// Начальные пользователи (чтобы демо-сценарии работали без регистрации)
+	if cfg.SeedUsername != "" {
+		if cfg.SeedPassword == "" {
+			logrusLogger.Fatal("AUTH_SEED_PASSWORD is required when AUTH_SEED_USERNAME is set")
+		}
+		if err := authService.EnsureUser(context.Background(), cfg.SeedUsername, cfg.SeedPassword,
+			[]string{service.RoleUser}); err != nil {
+			logrusLogger.WithError(err).Fatal("failed to create seed user")
+		}
+	}
+	if cfg.SeedAdminUsername != "" {
+		if cfg.SeedAdminPassword == "" {
+			logrusLogger.Fatal("AUTH_SEED_ADMIN_PASSWORD is required when AUTH_SEED_ADMIN_USERNAME is set")
+		}
+		if err := authService.EnsureUser(context.Background(), cfg.SeedAdminUsername, cfg.SeedAdminPassword,
+			[]string{service.RoleUser, service.RoleAdmin}); err != nil {
+			logrusLogger.WithError(err).Fatal("failed to create seed admin")
+		}
 	}
 
-	lis, err := net.Listen("tcp", ":"+grpcPort)
+	// Периодическая очистка истёкших сессий, счётчиков попыток входа и незавершённых входов
+	go func() {
+		ticker := time.NewTicker(10 * time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			n, err := authService.PurgeExpiredSessions(context.Background())
+			if err != nil {
+				logrusLogger.WithError(err).Warn("failed to purge expired sessions")
+			} else {
+				logrusLogger.WithField("count", n).Debug("expired sessions purged")
+			}
+			if _, err := guard.PurgeStale(context.Background()); err != nil {
+				logrusLogger.WithError(err).Warn("failed to purge login attempt counters")
+			}
+			if _, err := authService.PurgeExpiredChallenges(context.Background()); err != nil {
+				logrusLogger.WithError(err).Warn("failed to purge MFA challenges")
+			}
+			if _, err := authService.PurgeExpiredPasswordResets(context.Background()); err != nil {
+				logrusLogger.WithError(err).Warn("failed to purge password reset tokens")
+			}
+			if _, err := authService.PurgeExpiredOIDCLogins(context.Background()); err != nil {
+				logrusLogger.WithError(err).Warn("failed to purge OIDC login states")
+			}
+		}
+	}()
+
+	// Число действующих сессий считается при каждом опросе /metrics
+	metrics.RegisterActiveSessions(func() float64 {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		n, err := authService.ActiveSessions(ctx)
+		if err != nil {
+			logrusLogger.WithError(err).Warn("failed to count active sessions")
+			return math.NaN()
+		}
+		return float64(n)
+	})
+
+	// Инициализация хендлера
+	authHandler := httpHandler.NewAuthHandler(authService, logrusLogger)
+	authHandler.SetOIDCPostLoginURL(cfg.OIDCPostLoginURL)
+
+	// HTTP сервер для логина (порт 8081)
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/auth/login", authHandler.Login)
+	mux.HandleFunc("POST /v1/auth/login/2fa", authHandler.LoginMFA)
+	mux.HandleFunc("GET /v1/auth/oidc/login", authHandler.OIDCLogin)
+	mux.HandleFunc("GET /v1/auth/oidc/callback", authHandler.OIDCCallback)
+	mux.HandleFunc("POST /v1/auth/register", authHandler.Register)
+	mux.HandleFunc("POST /v1/auth/logout", authHandler.Logout)
+	mux.HandleFunc("POST /v1/auth/token", authHandler.Token)
+	mux.HandleFunc("POST /v1/auth/token/refresh", authHandler.RefreshToken)
+	mux.HandleFunc("POST /v1/auth/password/reset", authHandler.RequestPasswordReset)
+	mux.HandleFunc("POST /v1/auth/password/reset/confirm", authHandler.ConfirmPasswordReset)
+	mux.HandleFunc("GET /v1/auth/sessions", authHandler.ListSessions)
+	mux.HandleFunc("DELETE /v1/auth/sessions/{id}", authHandler.RevokeSession)
+	mux.HandleFunc("POST /v1/auth/sessions/revoke-others", authHandler.RevokeOtherSessions)
+	mux.HandleFunc("POST /v1/auth/tokens", authHandler.CreateAccessToken)
+	mux.HandleFunc("GET /v1/auth/tokens", authHandler.ListAccessTokens)
+	mux.HandleFunc("DELETE /v1/auth/tokens/{id}", authHandler.RevokeAccessToken)
+	mux.HandleFunc("PUT /v1/auth/users/{username}/roles", authHandler.SetUserRoles)
+	mux.HandleFunc("GET /v1/auth/audit", authHandler.ListAuditEvents)
+	mux.HandleFunc("GET /v1/auth/audit/export", authHandler.ExportAuditEvents)
+	mux.HandleFunc("POST /v1/auth/2fa/enroll", authHandler.EnrollTOTP)
+	mux.HandleFunc("POST /v1/auth/2fa/confirm", authHandler.ConfirmTOTP)
+	mux.HandleFunc("GET /.well-known/jwks.json", authHandler.JWKS)
+	mux.HandleFunc("GET /healthz", health.Healthz)
+	mux.HandleFunc("GET /readyz", health.Readyz)
+	mux.Handle("GET /metrics", middleware.MetricsHandler())
+
+	handler := httpHandler.ClientInfoMiddleware(mux)
+	handler = middleware.MetricsMiddleware(mux)(handler)
+	handler = middleware.LoggingMiddleware(handler)
+	handler = middleware.RequestIDMiddleware(handler)
+	runner.HTTP("auth-http", server.NewHTTPServer(":"+cfg.HTTPPort, handler))
+
+	// gRPC сервер для Verify (порт 50051)
+	lis, err := net.Listen("tcp", ":"+cfg.GRPCPort)
 	if err != nil {
 		logrusLogger.WithError(err).Fatal("failed to listen")
 	}
 
-	s := grpc.NewServer()
-	pb.RegisterAuthServiceServer(s, &grp.Server{Logger: logrusLogger})
+	// request-id, журнал вызовов, метрики и recovery для всех RPC
+	grpcOpts := interceptors.ServerOptions(logrusLogger)
+	if cfg.GRPCTLS.CertFile != "" {
+		reloader, err := tlsconfig.NewReloader(tlsconfig.Files{
+			CertFile: cfg.GRPCTLS.CertFile,
+			KeyFile:  cfg.GRPCTLS.KeyFile,
+			CAFile:   cfg.GRPCTLS.ClientCAFile,
+		}, logrusLogger)
+		if err != nil {
+			logrusLogger.WithError(err).Fatal("failed to load gRPC TLS certificates")
+		}
+		mutual := cfg.GRPCTLS.ClientCAFile != ""
+		tlsCfg, err := tlsconfig.Server(reloader, mutual)
+		if err != nil {
+			logrusLogger.WithError(err).Fatal("invalid gRPC TLS configuration")
+		}
+		grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(tlsCfg)))
+		logrusLogger.WithField("mtls", mutual).Info("gRPC TLS enabled")
+	} else {
+		logrusLogger.Warn("gRPC TLS is disabled, tokens are sent in plaintext")
+	}
+
+	// RevokeSessions и другие привилегированные RPC - только доверенным сервисам
+	callerAuth := grp.NewCallerAuth(cfg.GRPCTLS.AdminClients, cfg.GRPCTLS.ServiceToken, logrusLogger)
+	grpcOpts = append(grpcOpts, grpc.ChainUnaryInterceptor(callerAuth.UnaryInterceptor()))
+	if len(cfg.GRPCTLS.AdminClients) == 0 && cfg.GRPCTLS.ServiceToken == "" {
+		logrusLogger.Warn("no trusted gRPC callers configured, privileged RPCs are disabled")
+	}
+
+	s := grpc.NewServer(grpcOpts...)
+	pb.RegisterAuthServiceServer(s, &grp.Server{Logger: logrusLogger, Service: authService})
 	reflection.Register(s)
 
+	// grpc.health.v1.Health: общий статус ("") и статус AuthService следуют за проверками готовности
+	healthServer := grpchealth.NewServer()
+	healthpb.RegisterHealthServer(s, healthServer)
 	go func() {
-		logrusLogger.WithField("port", grpcPort).Info("Auth gRPC server starting")
-		if err := s.Serve(lis); err != nil {
-			logrusLogger.WithError(err).Fatal("failed to serve")
+		ticker := time.NewTicker(5 * time.Second)
+		defer ticker.Stop()
+		for {
+			_, ready := health.Ready(context.Background())
+			status := healthpb.HealthCheckResponse_SERVING
+			if !ready {
+				status = healthpb.HealthCheckResponse_NOT_SERVING
+			}
+			// После Shutdown статус больше не меняется
+			healthServer.SetServingStatus("", status)
+			healthServer.SetServingStatus(pb.AuthService_ServiceDesc.ServiceName, status)
+			<-ticker.C
 		}
 	}()
 
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-	logrusLogger.Info("Shutting down Auth server...")
-	s.GracefulStop()
+	runner.GRPC("auth-grpc", s, lis)
+
+	// Сначала снимаем готовность (HTTP и gRPC health), затем дожидаемся завершения текущих вызовов
+	runner.OnStop(healthServer.Shutdown)
+	runner.OnStop(authService.CloseRevocations) // завершает стримы WatchRevocations
+
+	if err := runner.Run(); err != nil {
+		logrusLogger.WithError(err).Fatal("auth service stopped with error")
+	}
 }
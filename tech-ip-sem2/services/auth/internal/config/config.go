@@ -0,0 +1,236 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type DatabaseConfig struct {
+	Host     string
+	Port     string
+	User     string
+	Password string
+	DBName   string
+	Driver   string // "postgres" или "memory"
+}
+
+type MailConfig struct {
+	Driver   string // "log" (файл или stdout) или "smtp"
+	File     string // для "log": путь к файлу, пустой - stdout
+	From     string
+	SMTPHost string
+	SMTPPort string
+	SMTPUser string
+	SMTPPass string
+}
+
+// DefaultCSRFSecret используется, если CSRF_SECRET не задан (только для разработки)
+const DefaultCSRFSecret = "dev-csrf-secret-change-me"
+
+// GRPCTLSConfig - TLS для gRPC-сервера (пустой CertFile - без TLS)
+type GRPCTLSConfig struct {
+	CertFile     string
+	KeyFile      string
+	ClientCAFile string // если задан, клиенты обязаны предъявить сертификат (mTLS)
+	// Кто может вызывать привилегированные RPC (RevokeSessions): имена (CN или DNS SAN)
+	// клиентских сертификатов и/или сервисный токен. Без них такие RPC всегда отклоняются.
+	AdminClients []string
+	ServiceToken string
+}
+
+type Config struct {
+	HTTPPort string
+	GRPCPort string
+	LogLevel string
+	DB       DatabaseConfig
+	Mail     MailConfig
+	GRPCTLS  GRPCTLSConfig
+
+	ShutdownTimeout time.Duration // сколько ждать завершения текущих запросов при остановке
+
+	SessionTTL         time.Duration // простой сессии до истечения (продлевается при активности)
+	SessionMaxLifetime time.Duration // абсолютное время жизни сессии
+	CSRFSecret         string        // общий с tasks service секрет для HMAC CSRF-токенов
+
+	JWTIssuer         string
+	AccessTokenTTL    time.Duration
+	RefreshTokenTTL   time.Duration
+	JWTRotationPeriod time.Duration // как часто выпускать новый ключ подписи
+
+	// Защита от перебора паролей
+	LoginMaxAttempts      int           // неудач без задержки на один username
+	LoginMaxAttemptsPerIP int           // неудач без задержки с одного IP
+	LoginBaseDelay        time.Duration // первая задержка, далее удваивается
+	LoginMaxLockout       time.Duration
+	LoginAttemptWindow    time.Duration // через сколько без неудач счётчик сбрасывается
+
+	// Двухфакторная аутентификация (TOTP)
+	TOTPIssuer      string
+	MFAChallengeTTL time.Duration // сколько ждать код после ввода пароля
+
+	// Вход через внешнего OIDC-провайдера (пустой OIDCIssuer - выключен)
+	OIDCIssuer       string
+	OIDCClientID     string
+	OIDCClientSecret string
+	OIDCRedirectURL  string
+	OIDCScopes       string // через пробел
+	OIDCPostLoginURL string
+
+	PasswordResetTTL time.Duration
+	PasswordResetURL string // ссылка из письма, токен дописывается в конец
+
+	// Пользователи, создаваемые при старте, если их ещё нет (пустой username - не создавать).
+	// Пароль обязателен и проверяется той же политикой, что и при регистрации.
+	SeedUsername      string // пользователь с ролью user, по умолчанию не создаётся
+	SeedPassword      string
+	SeedAdminUsername string // администратор (роли user и admin), по умолчанию не создаётся
+	SeedAdminPassword string
+}
+
+func Load() (*Config, error) {
+	cfg := &Config{
+		HTTPPort: getEnv("AUTH_HTTP_PORT", "8081"),
+		GRPCPort: getEnv("AUTH_GRPC_PORT", "50051"),
+		LogLevel: getEnv("LOG_LEVEL", "info"),
+		DB: DatabaseConfig{
+			Host:     getEnv("AUTH_DB_HOST", "localhost"),
+			Port:     getEnv("AUTH_DB_PORT", "5432"),
+			User:     getEnv("AUTH_DB_USER", "tasks_user"),
+			Password: getEnv("AUTH_DB_PASSWORD", "tasks_pass"),
+			DBName:   getEnv("AUTH_DB_NAME", "tasks_db"),
+			Driver:   getEnv("AUTH_DB_DRIVER", "memory"),
+		},
+		Mail: MailConfig{
+			Driver:   getEnv("AUTH_MAIL_DRIVER", "log"),
+			File:     getEnv("AUTH_MAIL_FILE", ""),
+			From:     getEnv("AUTH_MAIL_FROM", "no-reply@localhost"),
+			SMTPHost: getEnv("AUTH_SMTP_HOST", "localhost"),
+			SMTPPort: getEnv("AUTH_SMTP_PORT", "1025"),
+			SMTPUser: getEnv("AUTH_SMTP_USER", ""),
+			SMTPPass: getEnv("AUTH_SMTP_PASSWORD", ""),
+		},
+		GRPCTLS: GRPCTLSConfig{
+			CertFile:     getEnv("AUTH_GRPC_TLS_CERT", ""),
+			KeyFile:      getEnv("AUTH_GRPC_TLS_KEY", ""),
+			ClientCAFile: getEnv("AUTH_GRPC_TLS_CLIENT_CA", ""),
+			ServiceToken: getEnv("AUTH_GRPC_SERVICE_TOKEN", ""),
+		},
+		CSRFSecret:       getEnv("CSRF_SECRET", DefaultCSRFSecret),
+		JWTIssuer:        getEnv("AUTH_JWT_ISSUER", "auth-service"),
+		TOTPIssuer:       getEnv("AUTH_TOTP_ISSUER", "MIREA Tasks"),
+		OIDCIssuer:       getEnv("AUTH_OIDC_ISSUER", ""),
+		OIDCClientID:     getEnv("AUTH_OIDC_CLIENT_ID", ""),
+		OIDCClientSecret: getEnv("AUTH_OIDC_CLIENT_SECRET", ""),
+		OIDCRedirectURL: getEnv("AUTH_OIDC_REDIRECT_URL",
+			"http://localhost:8081/v1/auth/oidc/callback"),
+		OIDCScopes:       getEnv("AUTH_OIDC_SCOPES", "openid email profile"),
+		OIDCPostLoginURL: getEnv("AUTH_OIDC_POST_LOGIN_URL", "/"),
+		PasswordResetURL: getEnv("AUTH_PASSWORD_RESET_URL",
+			"http://localhost:8081/reset-password?token="),
+		SeedUsername:      getEnv("AUTH_SEED_USERNAME", ""),
+		SeedPassword:      getEnv("AUTH_SEED_PASSWORD", ""),
+		SeedAdminUsername: getEnv("AUTH_SEED_ADMIN_USERNAME", ""),
+		SeedAdminPassword: getEnv("AUTH_SEED_ADMIN_PASSWORD", ""),
+	}
+
+	var err error
+	if cfg.ShutdownTimeout, err = getDuration("AUTH_SHUTDOWN_TIMEOUT", 15*time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.SessionTTL, err = getDuration("AUTH_SESSION_TTL", time.Hour); err != nil {
+		return nil, err
+	}
+	if cfg.SessionMaxLifetime, err = getDuration("AUTH_SESSION_MAX_LIFETIME", 24*time.Hour); err != nil {
+		return nil, err
+	}
+	if cfg.AccessTokenTTL, err = getDuration("AUTH_ACCESS_TOKEN_TTL", 15*time.Minute); err != nil {
+		return nil, err
+	}
+	if cfg.RefreshTokenTTL, err = getDuration("AUTH_REFRESH_TOKEN_TTL", 30*24*time.Hour); err != nil {
+		return nil, err
+	}
+	if cfg.JWTRotationPeriod, err = getDuration("AUTH_JWT_ROTATION_PERIOD", 24*time.Hour); err != nil {
+		return nil, err
+	}
+	if cfg.LoginMaxAttempts, err = getInt("AUTH_LOGIN_MAX_ATTEMPTS", 5); err != nil {
+		return nil, err
+	}
+	if cfg.LoginMaxAttemptsPerIP, err = getInt("AUTH_LOGIN_MAX_ATTEMPTS_PER_IP", 20); err != nil {
+		return nil, err
+	}
+	if cfg.LoginBaseDelay, err = getDuration("AUTH_LOGIN_BASE_DELAY", time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.LoginMaxLockout, err = getDuration("AUTH_LOGIN_MAX_LOCKOUT", 15*time.Minute); err != nil {
+		return nil, err
+	}
+	if cfg.LoginAttemptWindow, err = getDuration("AUTH_LOGIN_ATTEMPT_WINDOW", time.Hour); err != nil {
+		return nil, err
+	}
+	if cfg.MFAChallengeTTL, err = getDuration("AUTH_MFA_CHALLENGE_TTL", 5*time.Minute); err != nil {
+		return nil, err
+	}
+	if cfg.PasswordResetTTL, err = getDuration("AUTH_PASSWORD_RESET_TTL", time.Hour); err != nil {
+		return nil, err
+	}
+	if (cfg.GRPCTLS.CertFile == "") != (cfg.GRPCTLS.KeyFile == "") {
+		return nil, fmt.Errorf("AUTH_GRPC_TLS_CERT and AUTH_GRPC_TLS_KEY must be set together")
+	}
+	if cfg.GRPCTLS.ClientCAFile != "" && cfg.GRPCTLS.CertFile == "" {
+		return nil, fmt.Errorf("AUTH_GRPC_TLS_CLIENT_CA requires AUTH_GRPC_TLS_CERT")
+	}
+	for _, name := range strings.Split(getEnv("AUTH_GRPC_ADMIN_CLIENTS", ""), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			cfg.GRPCTLS.AdminClients = append(cfg.GRPCTLS.AdminClients, name)
+		}
+	}
+	// Имя сертификата что-то значит, только если сертификаты клиентов проверяются
+	if len(cfg.GRPCTLS.AdminClients) > 0 && cfg.GRPCTLS.ClientCAFile == "" {
+		return nil, fmt.Errorf("AUTH_GRPC_ADMIN_CLIENTS requires AUTH_GRPC_TLS_CLIENT_CA")
+	}
+	return cfg, nil
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func getDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return d, nil
+}
+
+func getInt(key string, defaultValue int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return n, nil
+}
+
+func (db *DatabaseConfig) DSN() string {
+	switch db.Driver {
+	case "postgres":
+		return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+			db.Host, db.Port, db.User, db.Password, db.DBName)
+	default:
+		return ""
+	}
+}
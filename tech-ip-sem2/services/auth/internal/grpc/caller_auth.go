@@ -0,0 +1,117 @@
+package grpc
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	pb "github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/proto/auth"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/shared/middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// privilegedMethods - RPC, меняющие состояние пользователей. Их вызывают только доверенные
+// сервисы; проверки учётных данных (Verify*, WatchRevocations) доступны любому клиенту.
+var privilegedMethods = map[string]bool{
+	pb.AuthService_RevokeSessions_FullMethodName: true,
+}
+
+// CallerAuth проверяет, кто вызывает привилегированный RPC: клиентский сертификат mTLS
+// с разрешённым именем или сервисный токен в метаданных "authorization: Bearer <token>".
+// Если не настроено ни то ни другое, привилегированные RPC отклоняются всегда.
+type CallerAuth struct {
+	clients   map[string]bool // CN или DNS SAN клиентских сертификатов
+	tokenHash [sha256.Size]byte
+	hasToken  bool
+	logger    *logrus.Logger
+}
+
+func NewCallerAuth(clients []string, serviceToken string, logger *logrus.Logger) *CallerAuth {
+	a := &CallerAuth{clients: make(map[string]bool, len(clients)), logger: logger}
+	for _, name := range clients {
+		a.clients[name] = true
+	}
+	if serviceToken != "" {
+		a.tokenHash = sha256.Sum256([]byte(serviceToken))
+		a.hasToken = true
+	}
+	return a
+}
+
+// UnaryInterceptor пропускает непривилегированные вызовы без проверки
+func (a *CallerAuth) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if privilegedMethods[info.FullMethod] {
+			if err := a.authorize(ctx); err != nil {
+				a.logger.WithFields(logrus.Fields{
+					"component":  "grpc_server",
+					"request_id": middleware.GetRequestID(ctx),
+					"method":     info.FullMethod,
+					"caller":     strings.Join(peerNames(ctx), ","),
+				}).Warn("privileged call rejected")
+				return nil, err
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+func (a *CallerAuth) authorize(ctx context.Context) error {
+	names := peerNames(ctx)
+	for _, name := range names {
+		if a.clients[name] {
+			return nil
+		}
+	}
+
+	if token, ok := bearerToken(ctx); ok {
+		hash := sha256.Sum256([]byte(token))
+		if a.hasToken && subtle.ConstantTimeCompare(hash[:], a.tokenHash[:]) == 1 {
+			return nil
+		}
+		return status.Error(codes.Unauthenticated, "invalid service token")
+	}
+	if len(names) > 0 {
+		return status.Error(codes.PermissionDenied, "caller is not allowed to call this method")
+	}
+	return status.Error(codes.Unauthenticated, "caller credentials required")
+}
+
+// peerNames - CN и DNS SAN проверенного клиентского сертификата (пусто без mTLS)
+func peerNames(ctx context.Context) []string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	leaf := info.State.VerifiedChains[0][0]
+	names := append([]string(nil), leaf.DNSNames...)
+	if leaf.Subject.CommonName != "" {
+		names = append(names, leaf.Subject.CommonName)
+	}
+	return names
+}
+
+// bearerToken достаёт сервисный токен из метаданных authorization
+func bearerToken(ctx context.Context) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+	for _, v := range md.Get("authorization") {
+		if token, found := strings.CutPrefix(v, "Bearer "); found && token != "" {
+			return token, true
+		}
+	}
+	return "", false
+}
@@ -2,28 +2,25 @@ package grpc
 
 import (
 	"context"
+	"errors"
 
 	"github.com/sirupsen/logrus"
 	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/service"
 	pb "github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/proto/auth"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/shared/middleware"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type Server struct {
 	pb.UnimplementedAuthServiceServer
-	Logger *logrus.Logger
+	Logger  *logrus.Logger
+	Service *service.AuthService
 }
 
 func (s *Server) Verify(ctx context.Context, req *pb.VerifyRequest) (*pb.VerifyResponse, error) {
-	// Извлекаем request-id из входящих метаданных
-	var requestID string
-	if md, ok := metadata.FromIncomingContext(ctx); ok {
-		if values := md.Get("x-request-id"); len(values) > 0 {
-			requestID = values[0]
-		}
-	}
+	requestID := middleware.GetRequestID(ctx)
 
 	logEntry := s.Logger.WithFields(logrus.Fields{
 		"component":     "grpc_server",
@@ -31,16 +28,117 @@ func (s *Server) Verify(ctx context.Context, req *pb.VerifyRequest) (*pb.VerifyR
 		"token_present": req.Token != "",
 	})
 
-	valid, subject := service.VerifyToken(req.Token)
-	if !valid {
+	info, err := s.Service.VerifyToken(ctx, req.Token)
+	if err != nil {
+		logEntry.WithError(err).Error("token verification failed")
+		return nil, status.Error(codes.Internal, "verification failed")
+	}
+	if info == nil {
 		logEntry.Warn("invalid token attempt")
 		return nil, status.Error(codes.Unauthenticated, "invalid token")
 	}
 
-	logEntry.WithField("subject", subject).Info("token verified successfully")
+	logEntry.WithField("subject", info.Subject).Info("token verified successfully")
 
 	return &pb.VerifyResponse{
-		Valid:   true,
-		Subject: subject,
+		Valid:       true,
+		Subject:     info.Subject,
+		Roles:       info.Roles,
+		Scopes:      info.Scopes,
+		ExpiresAt:   timestamppb.New(info.ExpiresAt),
+		Permissions: info.Permissions,
 	}, nil
 }
+
+func (s *Server) VerifySession(ctx context.Context, req *pb.VerifySessionRequest) (*pb.VerifySessionResponse, error) {
+	requestID := middleware.GetRequestID(ctx)
+
+	logEntry := s.Logger.WithFields(logrus.Fields{
+		"component":       "grpc_server",
+		"request_id":      requestID,
+		"session_present": req.SessionId != "",
+	})
+
+	session, user, err := s.Service.ValidateSession(ctx, req.SessionId)
+	if err != nil {
+		logEntry.WithError(err).Error("session verification failed")
+		return nil, status.Error(codes.Internal, "verification failed")
+	}
+	if session == nil {
+		logEntry.Warn("invalid session attempt")
+		return nil, status.Error(codes.Unauthenticated, "invalid session")
+	}
+
+	logEntry.WithFields(logrus.Fields{
+		"subject":    user.Username,
+		"session_id": session.ID,
+	}).Debug("session verified successfully")
+
+	return &pb.VerifySessionResponse{
+		Valid:       true,
+		Subject:     user.Username,
+		Roles:       user.Roles,
+		ExpiresAt:   timestamppb.New(session.ExpiresAt),
+		Permissions: service.Permissions(user.Roles),
+	}, nil
+}
+
+func (s *Server) RevokeSessions(ctx context.Context, req *pb.RevokeSessionsRequest) (*pb.RevokeSessionsResponse, error) {
+	requestID := middleware.GetRequestID(ctx)
+	logEntry := s.Logger.WithFields(logrus.Fields{
+		"component":  "grpc_server",
+		"request_id": requestID,
+		"subject":    req.Subject,
+	})
+
+	if req.Subject == "" {
+		return nil, status.Error(codes.InvalidArgument, "subject is required")
+	}
+
+	revoked, err := s.Service.RevokeSessions(ctx, req.Subject)
+	if errors.Is(err, service.ErrUserNotFound) {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	if err != nil {
+		logEntry.WithError(err).Error("failed to revoke sessions")
+		return nil, status.Error(codes.Internal, "revocation failed")
+	}
+
+	logEntry.WithField("revoked", revoked).Info("sessions revoked")
+	return &pb.RevokeSessionsResponse{Revoked: revoked}, nil
+}
+
+func (s *Server) WatchRevocations(req *pb.WatchRevocationsRequest, stream pb.AuthService_WatchRevocationsServer) error {
+	ctx := stream.Context()
+	logEntry := s.Logger.WithFields(logrus.Fields{
+		"component":  "grpc_server",
+		"request_id": middleware.GetRequestID(ctx),
+	})
+
+	events, cancel := s.Service.SubscribeRevocations()
+	defer cancel()
+
+	// Событий до подписки клиент не видел - пусть сбросит кеш
+	if err := stream.Send(&pb.RevocationEvent{Flush: true}); err != nil {
+		return err
+	}
+	logEntry.Debug("revocation watcher subscribed")
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				logEntry.Debug("revocation feed closed")
+				return status.Error(codes.Unavailable, "revocation feed closed")
+			}
+			if err := stream.Send(&pb.RevocationEvent{
+				TokenHashes: event.TokenHashes,
+				Subject:     event.Subject,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+}
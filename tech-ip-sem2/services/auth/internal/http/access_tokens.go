@@ -0,0 +1,151 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/models"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/service"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/shared/middleware"
+)
+
+const defaultAccessTokenDays = 30
+
+type createAccessTokenRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays *int     `json:"expires_in_days,omitempty"` // по умолчанию 30
+}
+
+type accessTokenResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Token      string     `json:"token,omitempty"` // только в ответе на создание
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+func toAccessTokenResponse(t *models.AccessToken) accessTokenResponse {
+	return accessTokenResponse{
+		ID:         t.ID,
+		Name:       t.Name,
+		Scopes:     t.Scopes,
+		CreatedAt:  t.CreatedAt,
+		ExpiresAt:  t.ExpiresAt,
+		LastUsedAt: t.LastUsedAt,
+	}
+}
+
+// CreateAccessToken обрабатывает POST /v1/auth/tokens: создаёт персональный токен для скриптов
+func (h *AuthHandler) CreateAccessToken(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r.Context())
+	logEntry := h.logger.WithFields(logrus.Fields{
+		"component":  "http_handler",
+		"handler":    "CreateAccessToken",
+		"request_id": requestID,
+	})
+
+	user, ok := h.authenticate(w, r, logEntry)
+	if !ok {
+		return
+	}
+
+	var req createAccessTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid request body"})
+		return
+	}
+	days := defaultAccessTokenDays
+	if req.ExpiresInDays != nil {
+		days = *req.ExpiresInDays
+	}
+
+	token, stored, err := h.authService.CreateAccessToken(r.Context(), user, req.Name, req.Scopes, time.Duration(days)*24*time.Hour)
+	var verr *service.ValidationError
+	if errors.As(err, &verr) {
+		writeJSON(w, http.StatusUnprocessableEntity, errorResponse{Error: "validation failed", Fields: verr.Fields})
+		return
+	}
+	if err != nil {
+		logEntry.WithError(err).Error("failed to create access token")
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "internal server error"})
+		return
+	}
+
+	logEntry.WithFields(logrus.Fields{
+		"subject":  user.Username,
+		"token_id": stored.ID,
+		"scopes":   stored.Scopes,
+	}).Info("access token created")
+
+	resp := toAccessTokenResponse(stored)
+	resp.Token = token
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+// ListAccessTokens обрабатывает GET /v1/auth/tokens
+func (h *AuthHandler) ListAccessTokens(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r.Context())
+	logEntry := h.logger.WithFields(logrus.Fields{
+		"component":  "http_handler",
+		"handler":    "ListAccessTokens",
+		"request_id": requestID,
+	})
+
+	user, ok := h.authenticate(w, r, logEntry)
+	if !ok {
+		return
+	}
+
+	tokens, err := h.authService.ListAccessTokens(r.Context(), user)
+	if err != nil {
+		logEntry.WithError(err).Error("failed to list access tokens")
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "internal server error"})
+		return
+	}
+
+	resp := make([]accessTokenResponse, len(tokens))
+	for i, t := range tokens {
+		resp[i] = toAccessTokenResponse(t)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// RevokeAccessToken обрабатывает DELETE /v1/auth/tokens/{id}
+func (h *AuthHandler) RevokeAccessToken(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r.Context())
+	logEntry := h.logger.WithFields(logrus.Fields{
+		"component":  "http_handler",
+		"handler":    "RevokeAccessToken",
+		"request_id": requestID,
+	})
+
+	user, ok := h.authenticate(w, r, logEntry)
+	if !ok {
+		return
+	}
+
+	id := r.PathValue("id")
+	err := h.authService.RevokeAccessToken(r.Context(), user, id)
+	if errors.Is(err, service.ErrAccessTokenNotFound) {
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: "access token not found"})
+		return
+	}
+	if err != nil {
+		logEntry.WithError(err).Error("failed to revoke access token")
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "internal server error"})
+		return
+	}
+
+	logEntry.WithFields(logrus.Fields{
+		"subject":  user.Username,
+		"token_id": id,
+	}).Info("access token revoked")
+	w.WriteHeader(http.StatusNoContent)
+}
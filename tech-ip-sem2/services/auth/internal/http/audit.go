@@ -0,0 +1,158 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/models"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/repository"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/service"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/shared/middleware"
+)
+
+type auditEventsResponse struct {
+	Events []*models.AuditEvent `json:"events"`
+	// NextAfterID - курсор следующей страницы (after_id), если страница заполнена целиком
+	NextAfterID int64 `json:"next_after_id,omitempty"`
+}
+
+// parseAuditFilter разбирает параметры type, actor, since, until (RFC 3339), after_id и limit
+func parseAuditFilter(q url.Values) (repository.AuditFilter, *service.ValidationError) {
+	filter := repository.AuditFilter{
+		Type:  q.Get("type"),
+		Actor: q.Get("actor"),
+	}
+	verr := &service.ValidationError{}
+	parseTime := func(field string) time.Time {
+		v := q.Get(field)
+		if v == "" {
+			return time.Time{}
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			verr.Fields = append(verr.Fields, service.FieldError{Field: field, Message: "must be an RFC 3339 timestamp"})
+		}
+		return t
+	}
+	filter.Since = parseTime("since")
+	filter.Until = parseTime("until")
+	if v := q.Get("after_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id < 0 {
+			verr.Fields = append(verr.Fields, service.FieldError{Field: "after_id", Message: "must be a non-negative integer"})
+		}
+		filter.AfterID = id
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			verr.Fields = append(verr.Fields, service.FieldError{Field: "limit", Message: "must be a positive integer"})
+		}
+		filter.Limit = n
+	}
+	if len(verr.Fields) > 0 {
+		return filter, verr
+	}
+	return filter, nil
+}
+
+// ListAuditEvents обрабатывает GET /v1/auth/audit: страница журнала безопасности (только для администраторов)
+func (h *AuthHandler) ListAuditEvents(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r.Context())
+	logEntry := h.logger.WithFields(logrus.Fields{
+		"component":  "http_handler",
+		"handler":    "ListAuditEvents",
+		"request_id": requestID,
+	})
+
+	actor, ok := h.authenticate(w, r, logEntry)
+	if !ok {
+		return
+	}
+
+	filter, verr := parseAuditFilter(r.URL.Query())
+	if verr != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, errorResponse{Error: "validation failed", Fields: verr.Fields})
+		return
+	}
+
+	events, next, err := h.authService.AuditEvents(r.Context(), actor, filter)
+	if errors.Is(err, service.ErrForbidden) {
+		logEntry.WithField("subject", actor.Username).Warn("audit log access denied")
+		writeJSON(w, http.StatusForbidden, errorResponse{Error: "forbidden"})
+		return
+	}
+	if err != nil {
+		logEntry.WithError(err).Error("failed to list audit events")
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "internal server error"})
+		return
+	}
+
+	resp := auditEventsResponse{Events: events, NextAfterID: next}
+	if resp.Events == nil {
+		resp.Events = []*models.AuditEvent{}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// ExportAuditEvents обрабатывает GET /v1/auth/audit/export: весь журнал (с теми же фильтрами)
+// в формате JSON Lines, по одной записи на строку
+func (h *AuthHandler) ExportAuditEvents(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r.Context())
+	logEntry := h.logger.WithFields(logrus.Fields{
+		"component":  "http_handler",
+		"handler":    "ExportAuditEvents",
+		"request_id": requestID,
+	})
+
+	actor, ok := h.authenticate(w, r, logEntry)
+	if !ok {
+		return
+	}
+
+	filter, verr := parseAuditFilter(r.URL.Query())
+	if verr != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, errorResponse{Error: "validation failed", Fields: verr.Fields})
+		return
+	}
+	if !service.HasPermission(actor, service.PermAuditRead) {
+		logEntry.WithField("subject", actor.Username).Warn("audit log export denied")
+		writeJSON(w, http.StatusForbidden, errorResponse{Error: "forbidden"})
+		return
+	}
+
+	// Выгрузка может идти дольше общего WriteTimeout сервера
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		logEntry.WithError(err).Debug("cannot extend write deadline for export")
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit.jsonl"`)
+	w.Header().Set("Cache-Control", "no-store")
+
+	// Заголовки уже отправлены с первой записью, поэтому ошибку посреди выгрузки
+	// можно только залогировать: клиент увидит оборванный файл
+	enc := json.NewEncoder(w)
+	var exported int
+	err := h.authService.ExportAuditEvents(r.Context(), actor, filter, func(e *models.AuditEvent) error {
+		exported++
+		return enc.Encode(e)
+	})
+	if err != nil {
+		logEntry.WithError(err).WithField("exported", exported).Error("audit export failed")
+		if exported == 0 {
+			writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "internal server error"})
+		}
+		return
+	}
+
+	logEntry.WithFields(logrus.Fields{
+		"subject":  actor.Username,
+		"exported": exported,
+	}).Info("audit log exported")
+}
@@ -2,73 +2,482 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
+	"net"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/sirupsen/logrus"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/models"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/repository"
 	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/service"
 	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/shared/middleware"
 )
 
+// ClientInfoMiddleware сохраняет IP и User-Agent клиента в контексте для сервисного слоя
+func ClientInfoMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			ip = r.RemoteAddr
+		}
+		ctx := service.WithClientInfo(r.Context(), service.ClientInfo{
+			IP:        ip,
+			UserAgent: r.UserAgent(),
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+type AuthHandler struct {
+	authService      *service.AuthService
+	logger           *logrus.Logger
+	oidcPostLoginURL string // куда перенаправлять после входа через внешнего провайдера
+}
+
+func NewAuthHandler(as *service.AuthService, logger *logrus.Logger) *AuthHandler {
+	return &AuthHandler{
+		authService:      as,
+		logger:           logger,
+		oidcPostLoginURL: "/",
+	}
+}
+
+// SetOIDCPostLoginURL задаёт адрес, куда вернуть браузер после входа через провайдера
+func (h *AuthHandler) SetOIDCPostLoginURL(u string) {
+	h.oidcPostLoginURL = u
+}
+
 type loginRequest struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+	OTP      string `json:"otp,omitempty"` // только для /v1/auth/token при включённой 2FA
 }
 
-type loginResponse struct {
+type messageResponse struct {
 	Message string `json:"message"`
 }
 
-// LoginHandler обрабатывает POST /v1/auth/login и устанавливает cookies
-func LoginHandler(w http.ResponseWriter, r *http.Request) {
-	requestID := middleware.GetRequestID(r.Context())
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresIn int64  `json:"refresh_expires_in"`
+}
 
-	var req loginRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
-		return
-	}
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
 
-	// Проверка учётных данных (упрощённая)
-	if req.Username != "student" || req.Password != "student" {
-		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
-		return
+func toTokenResponse(p *service.TokenPair) tokenResponse {
+	return tokenResponse{
+		AccessToken:      p.AccessToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        int64(time.Until(p.AccessExpiresAt).Seconds()),
+		RefreshToken:     p.RefreshToken,
+		RefreshExpiresIn: int64(time.Until(p.RefreshExpiresAt).Seconds()),
 	}
+}
+
+type registerRequest struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type registerResponse struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
 
-	// Генерация session ID (в учебных целях - фиксированный)
-	sessionID := "demo-session-123"
+type errorResponse struct {
+	Error  string               `json:"error"`
+	Fields []service.FieldError `json:"fields,omitempty"`
+}
 
-	// Генерация CSRF токена
-	csrfToken := "demo-csrf-456"
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+const (
+	sessionCookieName = "session_id"
+	csrfCookieName    = "csrf_token"
+	csrfHeaderName    = "X-CSRF-Token"
+)
 
+// setSessionCookies устанавливает session и CSRF cookies после успешной аутентификации.
+// CSRF-токен - HMAC от session ID, поэтому меняется вместе с сессией при каждом логине.
+func setSessionCookies(w http.ResponseWriter, sessionToken, csrfToken string, maxAge time.Duration) {
 	// Установка session cookie (HttpOnly, Secure, SameSite=Lax)
 	http.SetCookie(w, &http.Cookie{
-		Name:     "session_id",
-		Value:    sessionID,
+		Name:     sessionCookieName,
+		Value:    sessionToken,
 		Path:     "/",
 		HttpOnly: true,
 		Secure:   true,
 		SameSite: http.SameSiteLaxMode,
-		MaxAge:   3600, // 1 час
+		MaxAge:   int(maxAge.Seconds()),
 	})
 
 	// Установка CSRF cookie (НЕ HttpOnly, чтобы JS мог прочитать)
 	http.SetCookie(w, &http.Cookie{
-		Name:     "csrf_token",
+		Name:     csrfCookieName,
 		Value:    csrfToken,
 		Path:     "/",
 		HttpOnly: false,
 		Secure:   true,
 		SameSite: http.SameSiteLaxMode,
-		MaxAge:   3600,
+		MaxAge:   int(maxAge.Seconds()),
 	})
+}
 
-	// Логирование
-	if service.Logger != nil {
-		service.Logger.WithField("request_id", requestID).Info("login successful, cookies set")
+// clearSessionCookies удаляет session и CSRF cookies в браузере
+func clearSessionCookies(w http.ResponseWriter) {
+	for _, c := range []struct {
+		name     string
+		httpOnly bool
+	}{{sessionCookieName, true}, {csrfCookieName, false}} {
+		http.SetCookie(w, &http.Cookie{
+			Name:     c.name,
+			Value:    "",
+			Path:     "/",
+			HttpOnly: c.httpOnly,
+			Secure:   true,
+			SameSite: http.SameSiteLaxMode,
+			MaxAge:   -1,
+		})
+	}
+}
+
+// setRetryAfter выставляет Retry-After в секундах (с округлением вверх)
+func setRetryAfter(w http.ResponseWriter, d time.Duration) {
+	seconds := int64((d + time.Second - 1) / time.Second)
+	w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+}
+
+func (h *AuthHandler) logLoginFailure(logEntry *logrus.Entry, r *http.Request, username string) {
+	logEntry.WithFields(logrus.Fields{
+		"username":  username,
+		"remote_ip": service.ClientInfoFromContext(r.Context()).IP,
+	}).Warn("login failed: invalid credentials")
+}
+
+func (h *AuthHandler) logLoginLocked(logEntry *logrus.Entry, r *http.Request, username string, locked *service.LockedError) {
+	logEntry.WithFields(logrus.Fields{
+		"username":    username,
+		"remote_ip":   service.ClientInfoFromContext(r.Context()).IP,
+		"retry_after": locked.RetryAfter.String(),
+	}).Warn("login rejected: too many failed attempts")
+}
+
+// startSession открывает новую серверную сессию для пользователя и выставляет cookies.
+// Сессия из cookie запроса (если есть) закрывается.
+func (h *AuthHandler) startSession(w http.ResponseWriter, r *http.Request, user *models.User) (*models.Session, error) {
+	var previousToken string
+	if c, err := r.Cookie(sessionCookieName); err == nil {
+		previousToken = c.Value
+	}
+
+	token, session, err := h.authService.CreateSession(r.Context(), user, previousToken)
+	if err != nil {
+		return nil, err
+	}
+	setSessionCookies(w, token, h.authService.CSRFToken(token), h.authService.SessionMaxAge())
+	return session, nil
+}
+
+// authenticate проверяет session cookie и, для изменяющих запросов, CSRF-токен.
+// При ошибке сам пишет ответ и возвращает ok=false.
+func (h *AuthHandler) authenticate(w http.ResponseWriter, r *http.Request, logEntry *logrus.Entry) (*models.User, bool) {
+	_, user, ok := h.authenticateSession(w, r, logEntry)
+	return user, ok
+}
+
+// authenticateSession - то же, что authenticate, но возвращает и текущую сессию
+func (h *AuthHandler) authenticateSession(w http.ResponseWriter, r *http.Request, logEntry *logrus.Entry) (*models.Session, *models.User, bool) {
+	c, err := r.Cookie(sessionCookieName)
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
+		return nil, nil, false
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		if !h.authService.ValidCSRF(c.Value, r.Header.Get(csrfHeaderName)) {
+			logEntry.Warn("CSRF token mismatch")
+			writeJSON(w, http.StatusForbidden, errorResponse{Error: "invalid csrf token"})
+			return nil, nil, false
+		}
+	}
+
+	session, user, err := h.authService.ValidateSession(r.Context(), c.Value)
+	if err != nil {
+		logEntry.WithError(err).Error("failed to validate session")
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "internal server error"})
+		return nil, nil, false
+	}
+	if user == nil {
+		writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
+		return nil, nil, false
+	}
+	return session, user, true
+}
+
+// Login обрабатывает POST /v1/auth/login и устанавливает cookies
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r.Context())
+	logEntry := h.logger.WithFields(logrus.Fields{
+		"component":  "http_handler",
+		"handler":    "Login",
+		"request_id": requestID,
+	})
+
+	var req loginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Проверка учётных данных через хранилище пользователей
+	user, err := h.authService.Login(r.Context(), req.Username, req.Password)
+	var locked *service.LockedError
+	if errors.As(err, &locked) {
+		h.logLoginLocked(logEntry, r, req.Username, locked)
+		setRetryAfter(w, locked.RetryAfter)
+		http.Error(w, "Too many failed login attempts", http.StatusTooManyRequests)
+		return
+	}
+	if errors.Is(err, service.ErrInvalidCredentials) {
+		h.logLoginFailure(logEntry, r, req.Username)
+		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		logEntry.WithError(err).Error("failed to check credentials")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
 	}
 
+	// При включённой 2FA cookies выдаются только после второго шага (/v1/auth/login/2fa)
+	if user.TOTPEnabled {
+		challengeID, expiresAt, err := h.authService.StartMFAChallenge(r.Context(), user)
+		if err != nil {
+			logEntry.WithError(err).Error("failed to start MFA challenge")
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		logEntry.WithField("subject", user.Username).Info("password accepted, second factor required")
+		writeJSON(w, http.StatusOK, mfaChallengeResponse{
+			Message:     "two-factor authentication required",
+			MFARequired: true,
+			ChallengeID: challengeID,
+			ExpiresIn:   int64(time.Until(expiresAt).Seconds()),
+		})
+		return
+	}
+
+	session, err := h.startSession(w, r, user)
+	if err != nil {
+		logEntry.WithError(err).Error("failed to create session")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	h.authService.RecordLogin(r.Context(), user, session, service.LoginMethodPassword)
+
+	// Логирование
+	logEntry.WithFields(logrus.Fields{
+		"subject":    user.Username,
+		"session_id": session.ID,
+	}).Info("login successful, cookies set")
+
 	// Ответ (без токена в теле, только подтверждение)
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(loginResponse{
+	json.NewEncoder(w).Encode(messageResponse{
 		Message: "login successful, cookies set",
 	})
 }
+
+// Register обрабатывает POST /v1/auth/register: создаёт пользователя и сразу открывает сессию
+func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r.Context())
+	logEntry := h.logger.WithFields(logrus.Fields{
+		"component":  "http_handler",
+		"handler":    "Register",
+		"request_id": requestID,
+	})
+
+	var req registerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid request body"})
+		return
+	}
+
+	user, err := h.authService.Register(r.Context(), req.Username, req.Email, req.Password)
+	var verr *service.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		logEntry.WithField("fields", len(verr.Fields)).Debug("registration validation failed")
+		writeJSON(w, http.StatusUnprocessableEntity, errorResponse{Error: "validation failed", Fields: verr.Fields})
+		return
+	case errors.Is(err, service.ErrUsernameTaken):
+		writeJSON(w, http.StatusConflict, errorResponse{
+			Error:  "validation failed",
+			Fields: []service.FieldError{{Field: "username", Message: "is already taken"}},
+		})
+		return
+	case errors.Is(err, service.ErrEmailTaken):
+		writeJSON(w, http.StatusConflict, errorResponse{
+			Error:  "validation failed",
+			Fields: []service.FieldError{{Field: "email", Message: "is already registered"}},
+		})
+		return
+	case errors.Is(err, repository.ErrAlreadyExists):
+		writeJSON(w, http.StatusConflict, errorResponse{Error: "username or email already taken"})
+		return
+	case err != nil:
+		logEntry.WithError(err).Error("failed to register user")
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "internal server error"})
+		return
+	}
+
+	session, err := h.startSession(w, r, user)
+	if err != nil {
+		logEntry.WithError(err).Error("failed to create session")
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "internal server error"})
+		return
+	}
+
+	logEntry.WithFields(logrus.Fields{
+		"subject":    user.Username,
+		"session_id": session.ID,
+	}).Info("user registered, cookies set")
+	writeJSON(w, http.StatusCreated, registerResponse{
+		ID:       user.ID,
+		Username: user.Username,
+		Email:    user.Email,
+	})
+}
+
+// Logout обрабатывает POST /v1/auth/logout: удаляет серверную сессию и очищает cookies.
+// Как и другие изменяющие запросы с session cookie, требует заголовок X-CSRF-Token.
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r.Context())
+	logEntry := h.logger.WithFields(logrus.Fields{
+		"component":  "http_handler",
+		"handler":    "Logout",
+		"request_id": requestID,
+	})
+
+	if c, err := r.Cookie(sessionCookieName); err == nil {
+		// Без проверки чужой сайт мог бы разлогинить пользователя (logout CSRF)
+		if !h.authService.ValidCSRF(c.Value, r.Header.Get(csrfHeaderName)) {
+			logEntry.Warn("CSRF token mismatch")
+			writeJSON(w, http.StatusForbidden, errorResponse{Error: "invalid csrf token"})
+			return
+		}
+		if err := h.authService.Logout(r.Context(), c.Value); err != nil {
+			logEntry.WithError(err).Error("failed to delete session")
+			writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "internal server error"})
+			return
+		}
+	}
+
+	clearSessionCookies(w)
+
+	logEntry.Info("logout successful, cookies cleared")
+	writeJSON(w, http.StatusOK, messageResponse{Message: "logout successful"})
+}
+
+// Token обрабатывает POST /v1/auth/token: выдаёт access-токен (JWT) для API и скриптов
+func (h *AuthHandler) Token(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r.Context())
+	logEntry := h.logger.WithFields(logrus.Fields{
+		"component":  "http_handler",
+		"handler":    "Token",
+		"request_id": requestID,
+	})
+
+	var req loginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid request body"})
+		return
+	}
+
+	pair, err := h.authService.IssueToken(r.Context(), req.Username, req.Password, req.OTP)
+	var locked *service.LockedError
+	if errors.As(err, &locked) {
+		h.logLoginLocked(logEntry, r, req.Username, locked)
+		setRetryAfter(w, locked.RetryAfter)
+		writeJSON(w, http.StatusTooManyRequests, errorResponse{Error: "too many failed login attempts"})
+		return
+	}
+	if errors.Is(err, service.ErrInvalidCredentials) {
+		h.logLoginFailure(logEntry, r, req.Username)
+		writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "invalid credentials"})
+		return
+	}
+	if errors.Is(err, service.ErrMFARequired) {
+		writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "otp required"})
+		return
+	}
+	if errors.Is(err, service.ErrInvalidOTP) {
+		logEntry.WithField("username", req.Username).Warn("token request rejected: invalid one-time code")
+		writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "invalid otp"})
+		return
+	}
+	if err != nil {
+		logEntry.WithError(err).Error("failed to issue token")
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "internal server error"})
+		return
+	}
+
+	logEntry.WithField("subject", req.Username).Info("access token issued")
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, toTokenResponse(pair))
+}
+
+// RefreshToken обрабатывает POST /v1/auth/token/refresh: обменивает refresh-токен на новую пару
+func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r.Context())
+	logEntry := h.logger.WithFields(logrus.Fields{
+		"component":  "http_handler",
+		"handler":    "RefreshToken",
+		"request_id": requestID,
+	})
+
+	var req refreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid request body"})
+		return
+	}
+
+	pair, err := h.authService.Refresh(r.Context(), req.RefreshToken)
+	switch {
+	case errors.Is(err, service.ErrRefreshTokenReused):
+		logEntry.Warn("refresh token reuse detected, token family revoked")
+		writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "invalid refresh token"})
+		return
+	case errors.Is(err, service.ErrInvalidRefreshToken):
+		writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "invalid refresh token"})
+		return
+	case err != nil:
+		logEntry.WithError(err).Error("failed to refresh token")
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "internal server error"})
+		return
+	}
+
+	logEntry.Info("token pair refreshed")
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, toTokenResponse(pair))
+}
+
+// JWKS обрабатывает GET /.well-known/jwks.json: открытые ключи для проверки access-токенов
+func (h *AuthHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	writeJSON(w, http.StatusOK, h.authService.JWKS())
+}
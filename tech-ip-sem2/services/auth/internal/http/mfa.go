@@ -0,0 +1,243 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/models"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/service"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/shared/middleware"
+)
+
+const (
+	mfaChallengeCookieName = "mfa_challenge"
+	mfaChallengeCookiePath = "/v1/auth/login/2fa"
+)
+
+// setMFAChallengeCookie передаёт вызов второго фактора после входа через провайдера:
+// в адресе перенаправления challenge_id попал бы в историю браузера, логи прокси и Referer
+func setMFAChallengeCookie(w http.ResponseWriter, challengeID string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     mfaChallengeCookieName,
+		Value:    challengeID,
+		Path:     mfaChallengeCookiePath,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   maxAge,
+	})
+}
+
+type mfaChallengeResponse struct {
+	Message     string `json:"message"`
+	MFARequired bool   `json:"mfa_required"`
+	ChallengeID string `json:"challenge_id"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+type loginMFARequest struct {
+	ChallengeID  string `json:"challenge_id"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// enrollRequest - текущий пароль; пользователю без пароля вместо него нужен недавний вход
+type enrollRequest struct {
+	Password string `json:"password"`
+}
+
+type enrollResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type confirmRequest struct {
+	Code     string `json:"code"`
+	Password string `json:"password"`
+}
+
+type confirmResponse struct {
+	Message       string   `json:"message"`
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// LoginMFA обрабатывает POST /v1/auth/login/2fa: второй шаг входа (TOTP или код восстановления).
+// challenge_id берётся из тела запроса, а если его нет - из cookie, выставленной при входе через провайдера.
+func (h *AuthHandler) LoginMFA(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r.Context())
+	logEntry := h.logger.WithFields(logrus.Fields{
+		"component":  "http_handler",
+		"handler":    "LoginMFA",
+		"request_id": requestID,
+	})
+
+	var req loginMFARequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid request body"})
+		return
+	}
+	if req.ChallengeID == "" {
+		if c, err := r.Cookie(mfaChallengeCookieName); err == nil {
+			req.ChallengeID = c.Value
+		}
+	}
+	if req.ChallengeID == "" || (req.Code == "") == (req.RecoveryCode == "") {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "challenge_id and exactly one of code or recovery_code are required"})
+		return
+	}
+
+	user, err := h.authService.CompleteMFAChallenge(r.Context(), req.ChallengeID, req.Code, req.RecoveryCode)
+	var locked *service.LockedError
+	switch {
+	case errors.As(err, &locked):
+		logEntry.WithFields(logrus.Fields{
+			"remote_ip":   service.ClientInfoFromContext(r.Context()).IP,
+			"retry_after": locked.RetryAfter.String(),
+		}).Warn("second factor rejected: too many failed attempts")
+		setRetryAfter(w, locked.RetryAfter)
+		writeJSON(w, http.StatusTooManyRequests, errorResponse{Error: "too many failed login attempts"})
+		return
+	case errors.Is(err, service.ErrInvalidChallenge):
+		setMFAChallengeCookie(w, "", -1)
+		writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "invalid or expired challenge"})
+		return
+	case errors.Is(err, service.ErrInvalidOTP):
+		logEntry.WithField("remote_ip", service.ClientInfoFromContext(r.Context()).IP).Warn("second factor rejected")
+		writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "invalid code"})
+		return
+	case err != nil:
+		logEntry.WithError(err).Error("failed to complete MFA challenge")
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "internal server error"})
+		return
+	}
+
+	// Вызов использован, cookie больше не нужна
+	setMFAChallengeCookie(w, "", -1)
+
+	session, err := h.startSession(w, r, user)
+	if err != nil {
+		logEntry.WithError(err).Error("failed to create session")
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "internal server error"})
+		return
+	}
+
+	h.authService.RecordLogin(r.Context(), user, session, service.LoginMethodMFA)
+
+	logEntry.WithFields(logrus.Fields{
+		"subject":       user.Username,
+		"session_id":    session.ID,
+		"recovery_used": req.RecoveryCode != "",
+	}).Info("login successful, cookies set")
+	writeJSON(w, http.StatusOK, messageResponse{Message: "login successful, cookies set"})
+}
+
+// EnrollTOTP обрабатывает POST /v1/auth/2fa/enroll: выдаёт секрет для приложения-аутентификатора
+func (h *AuthHandler) EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r.Context())
+	logEntry := h.logger.WithFields(logrus.Fields{
+		"component":  "http_handler",
+		"handler":    "EnrollTOTP",
+		"request_id": requestID,
+	})
+
+	session, user, ok := h.authenticateSession(w, r, logEntry)
+	if !ok {
+		return
+	}
+
+	var req enrollRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid request body"})
+		return
+	}
+
+	secret, uri, err := h.authService.EnrollTOTP(r.Context(), user, session, req.Password)
+	if h.writeReauthError(w, r, logEntry, user, err) {
+		return
+	}
+	if errors.Is(err, service.ErrTOTPAlreadyEnabled) {
+		writeJSON(w, http.StatusConflict, errorResponse{Error: "two-factor authentication already enabled"})
+		return
+	}
+	if err != nil {
+		logEntry.WithError(err).Error("failed to enroll TOTP")
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "internal server error"})
+		return
+	}
+
+	logEntry.WithField("subject", user.Username).Info("TOTP enrollment started")
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, enrollResponse{Secret: secret, OTPAuthURI: uri})
+}
+
+// ConfirmTOTP обрабатывает POST /v1/auth/2fa/confirm: включает 2FA и возвращает коды восстановления
+func (h *AuthHandler) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r.Context())
+	logEntry := h.logger.WithFields(logrus.Fields{
+		"component":  "http_handler",
+		"handler":    "ConfirmTOTP",
+		"request_id": requestID,
+	})
+
+	session, user, ok := h.authenticateSession(w, r, logEntry)
+	if !ok {
+		return
+	}
+
+	var req confirmRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid request body"})
+		return
+	}
+
+	codes, err := h.authService.ConfirmTOTP(r.Context(), user, session, req.Password, req.Code)
+	if h.writeReauthError(w, r, logEntry, user, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, service.ErrTOTPAlreadyEnabled):
+		writeJSON(w, http.StatusConflict, errorResponse{Error: "two-factor authentication already enabled"})
+		return
+	case errors.Is(err, service.ErrTOTPNotEnrolled):
+		writeJSON(w, http.StatusConflict, errorResponse{Error: "call /v1/auth/2fa/enroll first"})
+		return
+	case errors.Is(err, service.ErrInvalidOTP):
+		writeJSON(w, http.StatusUnprocessableEntity, errorResponse{Error: "invalid code"})
+		return
+	case err != nil:
+		logEntry.WithError(err).Error("failed to confirm TOTP")
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "internal server error"})
+		return
+	}
+
+	logEntry.WithField("subject", user.Username).Info("two-factor authentication enabled")
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, confirmResponse{
+		Message:       "two-factor authentication enabled",
+		RecoveryCodes: codes,
+	})
+}
+
+// writeReauthError отвечает на отказ в повторном подтверждении личности при подключении 2FA;
+// false - err к нему не относится
+func (h *AuthHandler) writeReauthError(w http.ResponseWriter, r *http.Request, logEntry *logrus.Entry, user *models.User, err error) bool {
+	var locked *service.LockedError
+	switch {
+	case errors.As(err, &locked):
+		h.logLoginLocked(logEntry, r, user.Username, locked)
+		setRetryAfter(w, locked.RetryAfter)
+		writeJSON(w, http.StatusTooManyRequests, errorResponse{Error: "too many failed login attempts"})
+		return true
+	case errors.Is(err, service.ErrReauthRequired):
+		logEntry.WithFields(logrus.Fields{
+			"subject":   user.Username,
+			"remote_ip": service.ClientInfoFromContext(r.Context()).IP,
+		}).Warn("two-factor change rejected: reauthentication failed")
+		writeJSON(w, http.StatusForbidden, errorResponse{Error: "current password is required; users without a password must sign in again"})
+		return true
+	}
+	return false
+}
@@ -0,0 +1,155 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/service"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/shared/middleware"
+)
+
+const (
+	oidcStateCookieName = "oidc_state"
+	oidcCookiePath      = "/v1/auth/oidc"
+)
+
+// setOIDCStateCookie привязывает state к браузеру, начавшему вход: без неё callback
+// с чужим кодом (login CSRF) будет отклонён
+func setOIDCStateCookie(w http.ResponseWriter, state string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookieName,
+		Value:    state,
+		Path:     oidcCookiePath,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode, // cookie должна прийти при переходе с сайта провайдера
+		MaxAge:   maxAge,
+	})
+}
+
+// OIDCLogin обрабатывает GET /v1/auth/oidc/login: перенаправляет браузер на страницу входа провайдера
+func (h *AuthHandler) OIDCLogin(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r.Context())
+	logEntry := h.logger.WithFields(logrus.Fields{
+		"component":  "http_handler",
+		"handler":    "OIDCLogin",
+		"request_id": requestID,
+	})
+
+	authURL, state, err := h.authService.StartOIDCLogin(r.Context())
+	if errors.Is(err, service.ErrOIDCDisabled) {
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: "oidc login is not configured"})
+		return
+	}
+	if err != nil {
+		logEntry.WithError(err).Error("failed to start oidc login")
+		writeJSON(w, http.StatusBadGateway, errorResponse{Error: "identity provider unavailable"})
+		return
+	}
+
+	setOIDCStateCookie(w, state, 600)
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// OIDCCallback обрабатывает GET /v1/auth/oidc/callback: завершает вход и выставляет
+// те же session и CSRF cookies, что и Login
+func (h *AuthHandler) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r.Context())
+	logEntry := h.logger.WithFields(logrus.Fields{
+		"component":  "http_handler",
+		"handler":    "OIDCCallback",
+		"request_id": requestID,
+	})
+
+	q := r.URL.Query()
+	state := q.Get("state")
+
+	// state одноразовый, cookie больше не нужна
+	setOIDCStateCookie(w, "", -1)
+
+	if idpErr := q.Get("error"); idpErr != "" {
+		logEntry.WithFields(logrus.Fields{
+			"error":       idpErr,
+			"description": q.Get("error_description"),
+		}).Warn("identity provider returned error")
+		writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "login at identity provider failed"})
+		return
+	}
+
+	c, err := r.Cookie(oidcStateCookieName)
+	if err != nil || state == "" || c.Value != state {
+		logEntry.Warn("oidc state mismatch")
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid state"})
+		return
+	}
+	code := q.Get("code")
+	if code == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "code is required"})
+		return
+	}
+
+	user, err := h.authService.CompleteOIDCLogin(r.Context(), state, code)
+	switch {
+	case errors.Is(err, service.ErrOIDCDisabled):
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: "oidc login is not configured"})
+		return
+	case errors.Is(err, service.ErrInvalidOIDCState):
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid or expired state"})
+		return
+	case err != nil:
+		logEntry.WithError(err).Warn("oidc login failed")
+		writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "oidc login failed"})
+		return
+	}
+
+	// Локально включённая 2FA действует и при входе через провайдера
+	if user.TOTPEnabled {
+		challengeID, expiresAt, err := h.authService.StartMFAChallenge(r.Context(), user)
+		if err != nil {
+			logEntry.WithError(err).Error("failed to start MFA challenge")
+			writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "internal server error"})
+			return
+		}
+		// challenge_id не попадает в адрес: страница отправляет на /v1/auth/login/2fa только код
+		setMFAChallengeCookie(w, challengeID, int(time.Until(expiresAt).Seconds()))
+		logEntry.WithField("subject", user.Username).Info("oidc login accepted, second factor required")
+		http.Redirect(w, r, h.postLoginURL(url.Values{"mfa_required": {"true"}}), http.StatusFound)
+		return
+	}
+
+	session, err := h.startSession(w, r, user)
+	if err != nil {
+		logEntry.WithError(err).Error("failed to create session")
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "internal server error"})
+		return
+	}
+
+	h.authService.RecordLogin(r.Context(), user, session, service.LoginMethodOIDC)
+
+	logEntry.WithFields(logrus.Fields{
+		"subject":    user.Username,
+		"session_id": session.ID,
+	}).Info("oidc login successful, cookies set")
+	http.Redirect(w, r, h.postLoginURL(nil), http.StatusFound)
+}
+
+// postLoginURL - куда вернуть браузер после входа через провайдера
+func (h *AuthHandler) postLoginURL(params url.Values) string {
+	target := h.oidcPostLoginURL
+	if len(params) == 0 {
+		return target
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return target
+	}
+	q := u.Query()
+	for k, v := range params {
+		q[k] = v
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
@@ -0,0 +1,89 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/service"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/shared/middleware"
+)
+
+type passwordResetRequest struct {
+	Email string `json:"email"`
+}
+
+type passwordResetConfirmRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+// RequestPasswordReset обрабатывает POST /v1/auth/password/reset.
+// Ответ всегда 202, чтобы нельзя было проверить, зарегистрирован ли email.
+func (h *AuthHandler) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r.Context())
+	logEntry := h.logger.WithFields(logrus.Fields{
+		"component":  "http_handler",
+		"handler":    "RequestPasswordReset",
+		"request_id": requestID,
+	})
+
+	var req passwordResetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid request body"})
+		return
+	}
+
+	if err := h.authService.RequestPasswordReset(r.Context(), req.Email); err != nil {
+		logEntry.WithError(err).Error("failed to send password reset email")
+	} else {
+		logEntry.WithField("remote_ip", service.ClientInfoFromContext(r.Context()).IP).Info("password reset requested")
+	}
+
+	writeJSON(w, http.StatusAccepted, messageResponse{
+		Message: "if the email is registered, a reset link has been sent",
+	})
+}
+
+// ConfirmPasswordReset обрабатывает POST /v1/auth/password/reset/confirm: задаёт новый пароль по токену из письма
+func (h *AuthHandler) ConfirmPasswordReset(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r.Context())
+	logEntry := h.logger.WithFields(logrus.Fields{
+		"component":  "http_handler",
+		"handler":    "ConfirmPasswordReset",
+		"request_id": requestID,
+	})
+
+	var req passwordResetConfirmRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid request body"})
+		return
+	}
+
+	err := h.authService.ConfirmPasswordReset(r.Context(), req.Token, req.NewPassword)
+	var verr *service.ValidationError
+	switch {
+	case errors.Is(err, service.ErrInvalidResetToken):
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid or expired reset token"})
+		return
+	case errors.As(err, &verr):
+		for i := range verr.Fields {
+			if verr.Fields[i].Field == "password" {
+				verr.Fields[i].Field = "new_password"
+			}
+		}
+		writeJSON(w, http.StatusUnprocessableEntity, errorResponse{Error: "validation failed", Fields: verr.Fields})
+		return
+	case err != nil:
+		logEntry.WithError(err).Error("failed to reset password")
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "internal server error"})
+		return
+	}
+
+	// Все сессии отозваны, в том числе текущая
+	clearSessionCookies(w)
+
+	logEntry.Info("password reset, all sessions revoked")
+	writeJSON(w, http.StatusOK, messageResponse{Message: "password has been reset, please log in again"})
+}
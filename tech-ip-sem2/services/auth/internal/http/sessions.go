@@ -0,0 +1,132 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/models"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/service"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/shared/middleware"
+)
+
+type sessionResponse struct {
+	ID         string    `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	Current    bool      `json:"current"` // сессия, с которой выполнен запрос
+}
+
+type revokeSessionsResponse struct {
+	Revoked int64 `json:"revoked"`
+}
+
+func toSessionResponse(s *models.Session, currentID string) sessionResponse {
+	return sessionResponse{
+		ID:         s.ID,
+		CreatedAt:  s.CreatedAt,
+		LastSeenAt: s.LastSeenAt,
+		ExpiresAt:  s.ExpiresAt,
+		IP:         s.IP,
+		UserAgent:  s.UserAgent,
+		Current:    s.ID == currentID,
+	}
+}
+
+// ListSessions обрабатывает GET /v1/auth/sessions: где пользователь сейчас залогинен
+func (h *AuthHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r.Context())
+	logEntry := h.logger.WithFields(logrus.Fields{
+		"component":  "http_handler",
+		"handler":    "ListSessions",
+		"request_id": requestID,
+	})
+
+	current, user, ok := h.authenticateSession(w, r, logEntry)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.authService.ListSessions(r.Context(), user)
+	if err != nil {
+		logEntry.WithError(err).Error("failed to list sessions")
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "internal server error"})
+		return
+	}
+
+	resp := make([]sessionResponse, len(sessions))
+	for i, s := range sessions {
+		resp[i] = toSessionResponse(s, current.ID)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// RevokeSession обрабатывает DELETE /v1/auth/sessions/{id}: выход на одном устройстве.
+// Закрытие текущей сессии равносильно logout.
+func (h *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r.Context())
+	logEntry := h.logger.WithFields(logrus.Fields{
+		"component":  "http_handler",
+		"handler":    "RevokeSession",
+		"request_id": requestID,
+	})
+
+	current, user, ok := h.authenticateSession(w, r, logEntry)
+	if !ok {
+		return
+	}
+
+	id := r.PathValue("id")
+	err := h.authService.RevokeSession(r.Context(), user, id)
+	if errors.Is(err, service.ErrSessionNotFound) {
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: "session not found"})
+		return
+	}
+	if err != nil {
+		logEntry.WithError(err).Error("failed to revoke session")
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "internal server error"})
+		return
+	}
+	if id == current.ID {
+		clearSessionCookies(w)
+	}
+
+	logEntry.WithFields(logrus.Fields{
+		"subject":    user.Username,
+		"session_id": id,
+	}).Info("session revoked")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokeOtherSessions обрабатывает POST /v1/auth/sessions/revoke-others:
+// выход на всех устройствах, кроме текущего
+func (h *AuthHandler) RevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r.Context())
+	logEntry := h.logger.WithFields(logrus.Fields{
+		"component":  "http_handler",
+		"handler":    "RevokeOtherSessions",
+		"request_id": requestID,
+	})
+
+	current, user, ok := h.authenticateSession(w, r, logEntry)
+	if !ok {
+		return
+	}
+
+	n, err := h.authService.RevokeOtherSessions(r.Context(), user, current.ID)
+	if err != nil {
+		logEntry.WithError(err).Error("failed to revoke sessions")
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "internal server error"})
+		return
+	}
+
+	logEntry.WithFields(logrus.Fields{
+		"subject": user.Username,
+		"revoked": n,
+	}).Info("other sessions revoked")
+	writeJSON(w, http.StatusOK, revokeSessionsResponse{Revoked: n})
+}
@@ -0,0 +1,79 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/service"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/shared/middleware"
+)
+
+type setRolesRequest struct {
+	Roles []string `json:"roles"`
+}
+
+type userRolesResponse struct {
+	Username    string   `json:"username"`
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
+
+// SetUserRoles обрабатывает PUT /v1/auth/users/{username}/roles (только для администраторов)
+func (h *AuthHandler) SetUserRoles(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r.Context())
+	logEntry := h.logger.WithFields(logrus.Fields{
+		"component":  "http_handler",
+		"handler":    "SetUserRoles",
+		"request_id": requestID,
+	})
+
+	actor, ok := h.authenticate(w, r, logEntry)
+	if !ok {
+		return
+	}
+
+	var req setRolesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid request body"})
+		return
+	}
+
+	username := r.PathValue("username")
+	user, err := h.authService.SetUserRoles(r.Context(), actor, username, req.Roles)
+	var verr *service.ValidationError
+	switch {
+	case errors.Is(err, service.ErrForbidden):
+		logEntry.WithFields(logrus.Fields{
+			"subject": actor.Username,
+			"target":  username,
+		}).Warn("role assignment denied")
+		writeJSON(w, http.StatusForbidden, errorResponse{Error: "forbidden"})
+		return
+	case errors.As(err, &verr):
+		writeJSON(w, http.StatusUnprocessableEntity, errorResponse{Error: "validation failed", Fields: verr.Fields})
+		return
+	case errors.Is(err, service.ErrSelfDemotion):
+		writeJSON(w, http.StatusConflict, errorResponse{Error: "cannot remove own admin role"})
+		return
+	case errors.Is(err, service.ErrUserNotFound):
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: "user not found"})
+		return
+	case err != nil:
+		logEntry.WithError(err).Error("failed to set user roles")
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "internal server error"})
+		return
+	}
+
+	logEntry.WithFields(logrus.Fields{
+		"subject": actor.Username,
+		"target":  user.Username,
+		"roles":   user.Roles,
+	}).Info("user roles updated")
+	writeJSON(w, http.StatusOK, userRolesResponse{
+		Username:    user.Username,
+		Roles:       user.Roles,
+		Permissions: service.Permissions(user.Roles),
+	})
+}
@@ -0,0 +1,47 @@
+// Package mailer отправляет служебные письма (сброс пароля и т.п.).
+// Для разработки письма пишутся в файл или stdout, в проде - через SMTP.
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+)
+
+// Message - простое текстовое письмо
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer - способ доставки писем
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// format собирает письмо в формате RFC 5322 (заголовки + текст)
+func format(from string, msg Message, now time.Time) []byte {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "From: %s\r\n", from)
+	fmt.Fprintf(&sb, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&sb, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&sb, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	sb.WriteString("MIME-Version: 1.0\r\n")
+	sb.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	sb.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	sb.WriteString("\r\n")
+	sb.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+	sb.WriteString("\r\n")
+	return []byte(sb.String())
+}
+
+// validAddress отсекает переводы строк, чтобы адрес нельзя было использовать для инъекции заголовков
+func validAddress(addr string) error {
+	if addr == "" || strings.ContainsAny(addr, "\r\n") {
+		return fmt.Errorf("mailer: invalid address %q", addr)
+	}
+	return nil
+}
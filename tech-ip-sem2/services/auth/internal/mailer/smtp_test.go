@@ -0,0 +1,225 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"mime"
+	"net"
+	"net/mail"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// received - то, что тестовый SMTP-сервер получил за одну сессию
+type received struct {
+	from string
+	to   []string
+	data string
+}
+
+// smtpServer - минимальный SMTP-сервер (RFC 5321) без TLS и аутентификации
+type smtpServer struct {
+	ln net.Listener
+
+	mu       sync.Mutex
+	conns    int
+	messages []received
+}
+
+func newSMTPServer(t *testing.T) *smtpServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &smtpServer{ln: ln}
+	t.Cleanup(func() { ln.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *smtpServer) addr() (host, port string) {
+	host, port, _ = net.SplitHostPort(s.ln.Addr().String())
+	return host, port
+}
+
+func (s *smtpServer) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns++
+		s.mu.Unlock()
+		go s.handle(conn)
+	}
+}
+
+func (s *smtpServer) handle(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	var msg received
+	reply("220 localhost ESMTP test")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimRight(line, "\r\n")
+		upper := strings.ToUpper(cmd)
+		switch {
+		case strings.HasPrefix(upper, "EHLO"), strings.HasPrefix(upper, "HELO"):
+			reply("250-localhost")
+			reply("250 8BITMIME")
+		case strings.HasPrefix(upper, "MAIL FROM:"):
+			msg.from = envelopeAddress(cmd[len("MAIL FROM:"):])
+			reply("250 OK")
+		case strings.HasPrefix(upper, "RCPT TO:"):
+			msg.to = append(msg.to, envelopeAddress(cmd[len("RCPT TO:"):]))
+			reply("250 OK")
+		case upper == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(l, ".")) // снимаем dot-stuffing
+			}
+			msg.data = data.String()
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			reply("250 OK")
+		case upper == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+// envelopeAddress достаёт адрес из "<addr> [параметры]"
+func envelopeAddress(arg string) string {
+	arg = strings.TrimSpace(arg)
+	if end := strings.Index(arg, ">"); strings.HasPrefix(arg, "<") && end > 0 {
+		return arg[1:end]
+	}
+	return arg
+}
+
+func (s *smtpServer) snapshot() (int, []received) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conns, append([]received(nil), s.messages...)
+}
+
+func newTestMailer(s *smtpServer) *SMTPMailer {
+	host, port := s.addr()
+	return NewSMTPMailer(SMTPConfig{Host: host, Port: port, From: "no-reply@tasks.local"})
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	srv := newSMTPServer(t)
+	m := newTestMailer(srv)
+
+	err := m.Send(context.Background(), Message{
+		To:      "alice@example.com",
+		Subject: "Сброс пароля",
+		Body:    "Ссылка:\nhttps://tasks.local/reset?token=abc\n.точка в начале строки",
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	_, messages := srv.snapshot()
+	if len(messages) != 1 {
+		t.Fatalf("server received %d messages, want 1", len(messages))
+	}
+	got := messages[0]
+	if got.from != "no-reply@tasks.local" {
+		t.Errorf("MAIL FROM = %q, want no-reply@tasks.local", got.from)
+	}
+	if len(got.to) != 1 || got.to[0] != "alice@example.com" {
+		t.Errorf("RCPT TO = %v, want [alice@example.com]", got.to)
+	}
+
+	parsed, err := mail.ReadMessage(strings.NewReader(got.data))
+	if err != nil {
+		t.Fatalf("message is not valid RFC 5322: %v", err)
+	}
+	h := parsed.Header
+	if h.Get("From") != "no-reply@tasks.local" || h.Get("To") != "alice@example.com" {
+		t.Errorf("From/To headers = %q/%q", h.Get("From"), h.Get("To"))
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(h.Get("Subject"))
+	if err != nil || subject != "Сброс пароля" {
+		t.Errorf("Subject = %q (%v), want decoded %q", h.Get("Subject"), err, "Сброс пароля")
+	}
+	if h.Get("Content-Type") != "text/plain; charset=utf-8" || h.Get("MIME-Version") != "1.0" {
+		t.Errorf("Content-Type/MIME-Version = %q/%q", h.Get("Content-Type"), h.Get("MIME-Version"))
+	}
+	if _, err := h.Date(); err != nil {
+		t.Errorf("Date header: %v", err)
+	}
+	if !strings.Contains(got.data, "\r\nСсылка:\r\nhttps://tasks.local/reset?token=abc\r\n.точка в начале строки\r\n") {
+		t.Errorf("body lines are not CRLF-terminated or lost a leading dot:\n%q", got.data)
+	}
+}
+
+func TestSMTPMailerRejectsHeaderInjection(t *testing.T) {
+	srv := newSMTPServer(t)
+	m := newTestMailer(srv)
+
+	for _, to := range []string{
+		"alice@example.com\r\nBcc: mallory@example.com",
+		"alice@example.com\nBcc: mallory@example.com",
+		"alice@example.com\rBcc: mallory@example.com",
+		"",
+	} {
+		if err := m.Send(context.Background(), Message{To: to, Subject: "s", Body: "b"}); err == nil {
+			t.Errorf("Send(To=%q) succeeded, want error", to)
+		}
+	}
+
+	// Адрес отклоняется до подключения к серверу
+	if conns, _ := srv.snapshot(); conns != 0 {
+		t.Errorf("server got %d connections, want 0", conns)
+	}
+}
+
+func TestSMTPMailerEncodesSubjectLineBreaks(t *testing.T) {
+	srv := newSMTPServer(t)
+	m := newTestMailer(srv)
+
+	err := m.Send(context.Background(), Message{
+		To:      "alice@example.com",
+		Subject: "hello\r\nBcc: mallory@example.com",
+		Body:    "b",
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	_, messages := srv.snapshot()
+	if len(messages) != 1 {
+		t.Fatalf("server received %d messages, want 1", len(messages))
+	}
+	parsed, err := mail.ReadMessage(strings.NewReader(messages[0].data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bcc := parsed.Header.Get("Bcc"); bcc != "" {
+		t.Errorf("injected Bcc header = %q", bcc)
+	}
+}
@@ -0,0 +1,47 @@
+package mailer
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// WriterMailer пишет письма целиком в io.Writer (stdout или файл) - для разработки
+type WriterMailer struct {
+	mu   sync.Mutex
+	w    io.Writer
+	from string
+}
+
+func NewWriterMailer(w io.Writer, from string) *WriterMailer {
+	return &WriterMailer{w: w, from: from}
+}
+
+// NewFileMailer дописывает письма в файл; пустой путь - stdout
+func NewFileMailer(path, from string) (*WriterMailer, error) {
+	if path == "" {
+		return NewWriterMailer(os.Stdout, from), nil
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return NewWriterMailer(f, from), nil
+}
+
+func (m *WriterMailer) Send(ctx context.Context, msg Message) error {
+	if err := validAddress(msg.To); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, err := m.w.Write(format(m.from, msg, time.Now())); err != nil {
+		return err
+	}
+	_, err := io.WriteString(m.w, "\r\n") // пустая строка между письмами
+	return err
+}
@@ -0,0 +1,93 @@
+// Package metrics содержит бизнес-метрики auth-сервиса для Prometheus.
+// HTTP-метрики (RED) собирает общий middleware.MetricsMiddleware.
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Исходы попыток входа
+const (
+	LoginSuccess            = "success"
+	LoginInvalidCredentials = "invalid_credentials"
+	LoginLocked             = "locked"
+	LoginInvalidOTP         = "invalid_otp"
+)
+
+// Виды проверяемых учётных данных
+const (
+	KindJWT     = "jwt"
+	KindPAT     = "pat"
+	KindSession = "session"
+)
+
+// Результаты проверки токена
+const (
+	ResultValid   = "valid"
+	ResultInvalid = "invalid"
+	ResultError   = "error"
+)
+
+var (
+	// Попытки входа по исходу (пароль, 2FA, выпуск токенов, SSO)
+	loginAttempts = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "auth_login_attempts_total",
+			Help: "Total number of login attempts by outcome",
+		},
+		[]string{"outcome"},
+	)
+
+	// Проверки токенов и сессий по виду и результату
+	tokenVerifications = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "auth_token_verifications_total",
+			Help: "Total number of token and session verifications by kind and result",
+		},
+		[]string{"kind", "result"},
+	)
+
+	// Длительность проверки (для pat и session включает запросы к хранилищу)
+	tokenVerificationDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "auth_token_verification_seconds",
+			Help:    "Duration of token and session verifications in seconds",
+			Buckets: []float64{0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5},
+		},
+		[]string{"kind"},
+	)
+)
+
+// LoginAttempt учитывает попытку входа с указанным исходом
+func LoginAttempt(outcome string) {
+	loginAttempts.WithLabelValues(outcome).Inc()
+}
+
+// TokenVerification учитывает проверку, начатую в start.
+// Результат определяется по ошибке и признаку валидности.
+func TokenVerification(kind string, start time.Time, valid bool, err error) {
+	result := ResultInvalid
+	switch {
+	case err != nil:
+		result = ResultError
+	case valid:
+		result = ResultValid
+	}
+	tokenVerifications.WithLabelValues(kind, result).Inc()
+	tokenVerificationDuration.WithLabelValues(kind).Observe(time.Since(start).Seconds())
+}
+
+// RegisterActiveSessions регистрирует gauge auth_active_sessions.
+// Значение вычисляется функцией count при каждом опросе /metrics.
+func RegisterActiveSessions(count func() float64) {
+	promauto.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Name: "auth_active_sessions",
+			Help: "Current number of unexpired sessions",
+		},
+		count,
+	)
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// AccessToken - именованный персональный токен для скриптов и автоматизации.
+// Хранится только хеш, сам токен показывается один раз при создании.
+type AccessToken struct {
+	ID         string
+	UserID     string
+	Name       string
+	TokenHash  string
+	Scopes     []string
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+	LastUsedAt *time.Time
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// AuditEvent - запись журнала безопасности. Записи только добавляются и не изменяются.
+type AuditEvent struct {
+	ID        int64             `json:"id"`
+	Time      time.Time         `json:"time"`
+	Type      string            `json:"type"`               // например login.failed, token.issued
+	Actor     string            `json:"actor,omitempty"`    // username, от имени которого произошло событие
+	ActorID   string            `json:"actor_id,omitempty"` // пусто, если пользователь неизвестен
+	IP        string            `json:"ip,omitempty"`
+	UserAgent string            `json:"user_agent,omitempty"`
+	RequestID string            `json:"request_id,omitempty"`
+	Details   map[string]string `json:"details,omitempty"`
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// UserIdentity связывает пользователя с учётной записью у внешнего OIDC-провайдера
+type UserIdentity struct {
+	Issuer    string
+	Subject   string // sub из ID-токена, стабилен в пределах провайдера
+	UserID    string
+	Email     string
+	CreatedAt time.Time
+}
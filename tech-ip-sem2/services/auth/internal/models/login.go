@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// LoginAttempts - счётчик неудачных попыток входа по ключу (username или IP)
+type LoginAttempts struct {
+	Failures      int
+	LastFailureAt time.Time
+}
+
+// MFAChallenge - незавершённый вход: пароль проверен, ожидается второй фактор
+type MFAChallenge struct {
+	ID        string
+	UserID    string
+	ExpiresAt time.Time
+	Attempts  int
+}
+
+// OIDCLogin - начатый вход через внешнего провайдера: ждём возврата пользователя на callback
+type OIDCLogin struct {
+	State        string
+	Nonce        string
+	CodeVerifier string
+	ExpiresAt    time.Time
+}
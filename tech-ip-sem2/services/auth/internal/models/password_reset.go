@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// PasswordReset - одноразовый токен сброса пароля (хранится только хеш)
+type PasswordReset struct {
+	ID        string
+	UserID    string
+	TokenHash string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// RefreshToken - одноразовый refresh-токен. Все токены, полученные ротацией
+// из одного логина, образуют семейство (FamilyID).
+type RefreshToken struct {
+	ID        string
+	FamilyID  string
+	UserID    string
+	TokenHash string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    *time.Time // токен уже обменян на новую пару
+	RevokedAt *time.Time // семейство отозвано
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// Session - серверная сессия пользователя.
+// Значение cookie не хранится, только его SHA-256 хеш (TokenHash).
+type Session struct {
+	ID         string    `json:"id"`
+	TokenHash  string    `json:"-"`
+	UserID     string    `json:"-"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	IP         string    `json:"ip"`         // адрес клиента при входе
+	UserAgent  string    `json:"user_agent"` // браузер/клиент при входе
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+type User struct {
+	ID           string    `json:"id"`
+	Username     string    `json:"username"`
+	Email        string    `json:"email"`
+	PasswordHash string    `json:"-"`
+	Roles        []string  `json:"roles"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"-"`
+
+	// Двухфакторная аутентификация (TOTP)
+	TOTPSecret    string   `json:"-"` // base32; задан, но не включён - идёт подключение
+	TOTPEnabled   bool     `json:"totp_enabled"`
+	TOTPLastStep  int64    `json:"-"` // последний принятый шаг, защита от повторного использования кода
+	RecoveryCodes []string `json:"-"` // SHA-256 хеши неиспользованных кодов восстановления
+}
@@ -0,0 +1,176 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+)
+
+const (
+	// minKeyRefresh ограничивает частоту перезагрузки JWKS при неизвестном kid
+	minKeyRefresh = 30 * time.Second
+	// keyTTL - через сколько перечитывать JWKS, чтобы отозванные провайдером ключи перестали действовать
+	keyTTL = time.Hour
+)
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type parsedKey struct {
+	kid string
+	alg string
+	key any
+}
+
+// keySet - кеш открытых ключей провайдера. Перезагружается при встрече неизвестного kid
+// (ротация ключей у провайдера), но не чаще minKeyRefresh.
+type keySet struct {
+	uri     string
+	getJSON func(ctx context.Context, url string, v any) error
+
+	mu        sync.Mutex
+	keys      []parsedKey
+	fetchedAt time.Time
+}
+
+func newKeySet(uri string, getJSON func(ctx context.Context, url string, v any) error) *keySet {
+	return &keySet{uri: uri, getJSON: getJSON}
+}
+
+func (s *keySet) key(ctx context.Context, kid, alg string) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if time.Since(s.fetchedAt) > keyTTL {
+		if err := s.refresh(ctx); err != nil && len(s.keys) == 0 {
+			return nil, err
+		}
+	}
+	if k, ok := s.lookup(kid, alg); ok {
+		return k, nil
+	}
+	if time.Since(s.fetchedAt) < minKeyRefresh {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if err := s.refresh(ctx); err != nil {
+		return nil, err
+	}
+	if k, ok := s.lookup(kid, alg); ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// lookup ищет ключ по kid; если kid в токене не указан, подходит единственный ключ
+func (s *keySet) lookup(kid, alg string) (any, bool) {
+	var found []parsedKey
+	for _, k := range s.keys {
+		if (kid == "" || k.kid == kid) && (k.alg == "" || k.alg == alg) {
+			found = append(found, k)
+		}
+	}
+	if len(found) != 1 {
+		return nil, false
+	}
+	return found[0].key, true
+}
+
+func (s *keySet) refresh(ctx context.Context) error {
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := s.getJSON(ctx, s.uri, &doc); err != nil {
+		return fmt.Errorf("oidc jwks: %w", err)
+	}
+
+	keys := make([]parsedKey, 0, len(doc.Keys))
+	for _, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.publicKey()
+		if err != nil {
+			continue // неподдерживаемые ключи пропускаем
+		}
+		keys = append(keys, parsedKey{kid: k.Kid, alg: k.Alg, key: pub})
+	}
+	s.keys = keys
+	s.fetchedAt = time.Now()
+	return nil
+}
+
+func (k jwk) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("rsa exponent too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("ec point is not on curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid base64url integer")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,220 @@
+package oidc_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/oidc"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/oidc/oidctest"
+)
+
+const (
+	testClientID    = "tasks-app"
+	testRedirectURL = "http://localhost:8081/v1/auth/oidc/callback"
+)
+
+func newProvider(t *testing.T) (*oidctest.IdP, *oidc.Provider) {
+	t.Helper()
+	idp := oidctest.New(testClientID)
+	t.Cleanup(idp.Close)
+	p := oidc.NewProvider(oidc.Config{
+		IssuerURL:   idp.Issuer(),
+		ClientID:    testClientID,
+		RedirectURL: testRedirectURL,
+		Scopes:      []string{"email", "profile"},
+	}, idp.Server.Client())
+	return idp, p
+}
+
+// login проходит authorization code flow до ответа token endpoint
+func login(t *testing.T, idp *oidctest.IdP, p *oidc.Provider, id oidctest.Identity, nonce string) *oidc.Tokens {
+	t.Helper()
+	verifier, challenge, err := oidc.NewPKCE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	authURL, err := p.AuthCodeURL(context.Background(), "state-1", nonce, challenge)
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	code, _, err := idp.Authorize(authURL, id)
+	if err != nil {
+		t.Fatalf("Authorize: %v", err)
+	}
+	tokens, err := p.Exchange(context.Background(), code, verifier)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	return tokens
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	idp, p := newProvider(t)
+
+	authURL, err := p.AuthCodeURL(context.Background(), "st", "nc", "challenge")
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := u.Scheme + "://" + u.Host + u.Path; got != idp.Issuer()+"/authorize" {
+		t.Errorf("authorization endpoint = %s, want one from discovery", got)
+	}
+	q := u.Query()
+	want := map[string]string{
+		"response_type":         "code",
+		"client_id":             testClientID,
+		"redirect_uri":          testRedirectURL,
+		"scope":                 "openid email profile",
+		"state":                 "st",
+		"nonce":                 "nc",
+		"code_challenge":        "challenge",
+		"code_challenge_method": "S256",
+	}
+	for k, v := range want {
+		if q.Get(k) != v {
+			t.Errorf("%s = %q, want %q", k, q.Get(k), v)
+		}
+	}
+}
+
+func TestDiscoveryIssuerMismatch(t *testing.T) {
+	idp := oidctest.New(testClientID)
+	defer idp.Close()
+
+	// Документ discovery обязан называть тот же issuer, по которому его запросили
+	p := oidc.NewProvider(oidc.Config{
+		IssuerURL: strings.Replace(idp.Issuer(), "127.0.0.1", "localhost", 1),
+		ClientID:  testClientID,
+	}, idp.Server.Client())
+	if _, err := p.AuthCodeURL(context.Background(), "st", "nc", "ch"); err == nil {
+		t.Fatal("expected issuer mismatch error")
+	}
+}
+
+func TestExchangeAndVerify(t *testing.T) {
+	idp, p := newProvider(t)
+	id := oidctest.Identity{Subject: "idp-42", Email: "Alice@Example.com", EmailVerified: true, PreferredUsername: "alice"}
+
+	tokens := login(t, idp, p, id, "nonce-1")
+	claims, err := p.VerifyIDToken(context.Background(), tokens.IDToken, "nonce-1")
+	if err != nil {
+		t.Fatalf("VerifyIDToken: %v", err)
+	}
+	if claims.Subject != id.Subject || claims.Email != id.Email || !claims.EmailVerified || claims.PreferredUsername != "alice" {
+		t.Errorf("unexpected claims: %+v", claims)
+	}
+}
+
+func TestExchangeRequiresPKCEVerifier(t *testing.T) {
+	idp, p := newProvider(t)
+	_, challenge, err := oidc.NewPKCE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	authURL, err := p.AuthCodeURL(context.Background(), "st", "nc", challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, _, err := idp.Authorize(authURL, oidctest.Identity{Subject: "s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Перехваченный код без исходного verifier бесполезен
+	otherVerifier, _, _ := oidc.NewPKCE()
+	if _, err := p.Exchange(context.Background(), code, otherVerifier); err == nil {
+		t.Fatal("expected exchange with wrong code_verifier to fail")
+	}
+}
+
+func TestVerifyIDTokenRejects(t *testing.T) {
+	idp, p := newProvider(t)
+	id := oidctest.Identity{Subject: "idp-42"}
+	_, foreignKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name    string
+		token   func() string
+		nonce   string
+		wantErr error
+	}{
+		{
+			name:    "foreign signature",
+			token:   func() string { return oidctest.SignWith(foreignKey, idp.KeyID(), idp.Claims(id, "n")) },
+			nonce:   "n",
+			wantErr: oidc.ErrInvalidIDToken,
+		},
+		{
+			name: "wrong audience",
+			token: func() string {
+				c := idp.Claims(id, "n")
+				c["aud"] = "another-client"
+				return idp.SignIDToken(c)
+			},
+			nonce:   "n",
+			wantErr: oidc.ErrInvalidIDToken,
+		},
+		{
+			name: "multiple audiences without azp",
+			token: func() string {
+				c := idp.Claims(id, "n")
+				c["aud"] = []string{testClientID, "another-client"}
+				return idp.SignIDToken(c)
+			},
+			nonce:   "n",
+			wantErr: oidc.ErrInvalidIDToken,
+		},
+		{
+			name: "wrong issuer",
+			token: func() string {
+				c := idp.Claims(id, "n")
+				c["iss"] = "https://evil.example"
+				return idp.SignIDToken(c)
+			},
+			nonce:   "n",
+			wantErr: oidc.ErrInvalidIDToken,
+		},
+		{
+			name: "expired",
+			token: func() string {
+				c := idp.Claims(id, "n")
+				c["exp"] = time.Now().Add(-time.Hour).Unix()
+				return idp.SignIDToken(c)
+			},
+			nonce:   "n",
+			wantErr: oidc.ErrInvalidIDToken,
+		},
+		{
+			name:    "nonce mismatch",
+			token:   func() string { return idp.SignIDToken(idp.Claims(id, "other")) },
+			nonce:   "n",
+			wantErr: oidc.ErrNonceMismatch,
+		},
+		{
+			name:    "empty expected nonce",
+			token:   func() string { return idp.SignIDToken(idp.Claims(id, "")) },
+			nonce:   "",
+			wantErr: oidc.ErrNonceMismatch,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := p.VerifyIDToken(context.Background(), tc.token(), tc.nonce)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("VerifyIDToken error = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
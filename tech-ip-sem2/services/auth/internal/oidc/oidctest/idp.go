@@ -0,0 +1,223 @@
+// Package oidctest - тестовый OpenID Connect провайдер на httptest.Server:
+// discovery, JWKS (Ed25519) и token endpoint с проверкой PKCE (S256).
+// Страницу входа заменяет метод Authorize, который сразу выдаёт код.
+package oidctest
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Identity - учётная запись пользователя у провайдера
+type Identity struct {
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	PreferredUsername string
+}
+
+// grant - выданный, но ещё не обменянный authorization code
+type grant struct {
+	clientID      string
+	redirectURI   string
+	nonce         string
+	codeChallenge string
+	identity      Identity
+}
+
+type IdP struct {
+	Server   *httptest.Server
+	ClientID string
+
+	kid string
+	key ed25519.PrivateKey
+
+	mu     sync.Mutex
+	grants map[string]grant
+}
+
+// New запускает провайдера для клиента clientID; остановить - Close
+func New(clientID string) *IdP {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	p := &IdP{ClientID: clientID, kid: "test-key", key: key, grants: make(map[string]grant)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("GET /jwks", p.jwks)
+	mux.HandleFunc("POST /token", p.token)
+	p.Server = httptest.NewServer(mux)
+	return p
+}
+
+func (p *IdP) Close() {
+	p.Server.Close()
+}
+
+// Issuer - идентификатор провайдера (адрес сервера)
+func (p *IdP) Issuer() string {
+	return p.Server.URL
+}
+
+// KeyID - kid ключа, которым провайдер подписывает ID-токены
+func (p *IdP) KeyID() string {
+	return p.kid
+}
+
+// Authorize имитирует вход пользователя на странице провайдера: проверяет параметры
+// запроса авторизации и возвращает code и state, с которыми браузер вернулся бы в callback
+func (p *IdP) Authorize(authURL string, id Identity) (code, state string, err error) {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		return "", "", err
+	}
+	q := u.Query()
+	switch {
+	case q.Get("response_type") != "code":
+		return "", "", errors.New("response_type must be code")
+	case q.Get("client_id") != p.ClientID:
+		return "", "", errors.New("unknown client_id")
+	case q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "":
+		return "", "", errors.New("PKCE S256 is required")
+	case q.Get("nonce") == "" || q.Get("state") == "":
+		return "", "", errors.New("state and nonce are required")
+	}
+
+	code = randomString()
+	p.mu.Lock()
+	p.grants[code] = grant{
+		clientID:      q.Get("client_id"),
+		redirectURI:   q.Get("redirect_uri"),
+		nonce:         q.Get("nonce"),
+		codeChallenge: q.Get("code_challenge"),
+		identity:      id,
+	}
+	p.mu.Unlock()
+	return code, q.Get("state"), nil
+}
+
+// SignIDToken подписывает произвольные claims ключом провайдера
+func (p *IdP) SignIDToken(claims jwt.MapClaims) string {
+	return SignWith(p.key, p.kid, claims)
+}
+
+// SignWith подписывает claims заданным ключом (например, чужим - для проверки подписи)
+func SignWith(key ed25519.PrivateKey, kid string, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		panic(err)
+	}
+	return signed
+}
+
+// Claims - стандартный набор claims ID-токена для пользователя id
+func (p *IdP) Claims(id Identity, nonce string) jwt.MapClaims {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":   p.Issuer(),
+		"sub":   id.Subject,
+		"aud":   p.ClientID,
+		"iat":   now.Unix(),
+		"exp":   now.Add(5 * time.Minute).Unix(),
+		"nonce": nonce,
+	}
+	if id.Email != "" {
+		claims["email"] = id.Email
+		claims["email_verified"] = id.EmailVerified
+	}
+	if id.PreferredUsername != "" {
+		claims["preferred_username"] = id.PreferredUsername
+	}
+	return claims
+}
+
+func (p *IdP) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                p.Issuer(),
+		"authorization_endpoint":                p.Issuer() + "/authorize",
+		"token_endpoint":                        p.Issuer() + "/token",
+		"jwks_uri":                              p.Issuer() + "/jwks",
+		"id_token_signing_alg_values_supported": []string{"EdDSA"},
+	})
+}
+
+func (p *IdP) jwks(w http.ResponseWriter, r *http.Request) {
+	pub := p.key.Public().(ed25519.PublicKey)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "OKP",
+			"crv": "Ed25519",
+			"kid": p.kid,
+			"use": "sig",
+			"alg": "EdDSA",
+			"x":   base64.RawURLEncoding.EncodeToString(pub),
+		}},
+	})
+}
+
+// token - обмен кода (RFC 6749 4.1.3) с проверкой code_verifier (RFC 7636 4.6)
+func (p *IdP) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	code := r.PostForm.Get("code")
+	p.mu.Lock()
+	g, ok := p.grants[code]
+	delete(p.grants, code) // код одноразовый
+	p.mu.Unlock()
+
+	// Публичный клиент передаёт client_id в форме, конфиденциальный - в Basic auth
+	clientID := r.PostForm.Get("client_id")
+	if id, _, basic := r.BasicAuth(); basic {
+		clientID, _ = url.QueryUnescape(id)
+	}
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	switch {
+	case !ok:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "unknown code"})
+	case clientID != g.clientID:
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+	case r.PostForm.Get("redirect_uri") != g.redirectURI:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "redirect_uri mismatch"})
+	case base64.RawURLEncoding.EncodeToString(sum[:]) != g.codeChallenge:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "PKCE verification failed"})
+	default:
+		writeJSON(w, http.StatusOK, map[string]string{
+			"id_token":     p.SignIDToken(p.Claims(g.identity, g.nonce)),
+			"access_token": randomString(),
+			"token_type":   "Bearer",
+		})
+	}
+}
+
+func randomString() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/models"
+)
+
+// MemoryAccessTokenRepository - хранилище персональных токенов в памяти (для разработки и демо)
+type MemoryAccessTokenRepository struct {
+	mu     sync.RWMutex
+	tokens map[string]*models.AccessToken // token hash -> token
+}
+
+func NewMemoryAccessTokenRepository() *MemoryAccessTokenRepository {
+	return &MemoryAccessTokenRepository{tokens: make(map[string]*models.AccessToken)}
+}
+
+func copyAccessToken(t *models.AccessToken) *models.AccessToken {
+	c := *t
+	c.Scopes = append([]string(nil), t.Scopes...)
+	if t.LastUsedAt != nil {
+		at := *t.LastUsedAt
+		c.LastUsedAt = &at
+	}
+	return &c
+}
+
+func (r *MemoryAccessTokenRepository) Create(ctx context.Context, token *models.AccessToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tokens[token.TokenHash]; ok {
+		return ErrAlreadyExists
+	}
+	r.tokens[token.TokenHash] = copyAccessToken(token)
+	return nil
+}
+
+func (r *MemoryAccessTokenRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.AccessToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, ok := r.tokens[tokenHash]
+	if !ok {
+		return nil, nil
+	}
+	return copyAccessToken(t), nil
+}
+
+func (r *MemoryAccessTokenRepository) ListByUserID(ctx context.Context, userID string) ([]*models.AccessToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var tokens []*models.AccessToken
+	for _, t := range r.tokens {
+		if t.UserID == userID {
+			tokens = append(tokens, copyAccessToken(t))
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].CreatedAt.After(tokens[j].CreatedAt) })
+	return tokens, nil
+}
+
+func (r *MemoryAccessTokenRepository) TouchLastUsed(ctx context.Context, id string, lastUsedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, t := range r.tokens {
+		if t.ID == id {
+			at := lastUsedAt
+			t.LastUsedAt = &at
+			return nil
+		}
+	}
+	return nil
+}
+
+func (r *MemoryAccessTokenRepository) Delete(ctx context.Context, userID, id string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for hash, t := range r.tokens {
+		if t.ID == id && t.UserID == userID {
+			delete(r.tokens, hash)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *MemoryAccessTokenRepository) DeleteByUserID(ctx context.Context, userID string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var n int64
+	for hash, t := range r.tokens {
+		if t.UserID == userID {
+			delete(r.tokens, hash)
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/models"
+)
+
+type PostgresAccessTokenRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresAccessTokenRepository(db *sql.DB) *PostgresAccessTokenRepository {
+	return &PostgresAccessTokenRepository{db: db}
+}
+
+const accessTokenColumns = `id, user_id, name, token_hash, scopes, created_at, expires_at, last_used_at`
+
+func scanAccessToken(row interface{ Scan(...any) error }) (*models.AccessToken, error) {
+	token := &models.AccessToken{}
+	var lastUsedAt sql.NullTime
+	err := row.Scan(&token.ID, &token.UserID, &token.Name, &token.TokenHash,
+		pq.Array(&token.Scopes), &token.CreatedAt, &token.ExpiresAt, &lastUsedAt)
+	if err != nil {
+		return nil, err
+	}
+	if lastUsedAt.Valid {
+		token.LastUsedAt = &lastUsedAt.Time
+	}
+	return token, nil
+}
+
+func (r *PostgresAccessTokenRepository) Create(ctx context.Context, token *models.AccessToken) error {
+	query := `INSERT INTO access_tokens (id, user_id, name, token_hash, scopes, created_at, expires_at)
+              VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err := r.db.ExecContext(ctx, query,
+		token.ID, token.UserID, token.Name, token.TokenHash, pq.Array(token.Scopes), token.CreatedAt, token.ExpiresAt)
+	if isUniqueViolation(err) {
+		return ErrAlreadyExists
+	}
+	return err
+}
+
+func (r *PostgresAccessTokenRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.AccessToken, error) {
+	query := `SELECT ` + accessTokenColumns + ` FROM access_tokens WHERE token_hash = $1`
+	token, err := scanAccessToken(r.db.QueryRowContext(ctx, query, tokenHash))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func (r *PostgresAccessTokenRepository) ListByUserID(ctx context.Context, userID string) ([]*models.AccessToken, error) {
+	query := `SELECT ` + accessTokenColumns + ` FROM access_tokens WHERE user_id = $1 ORDER BY created_at DESC`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []*models.AccessToken
+	for rows.Next() {
+		token, err := scanAccessToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, rows.Err()
+}
+
+func (r *PostgresAccessTokenRepository) TouchLastUsed(ctx context.Context, id string, lastUsedAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE access_tokens SET last_used_at = $1 WHERE id = $2`, lastUsedAt, id)
+	return err
+}
+
+func (r *PostgresAccessTokenRepository) Delete(ctx context.Context, userID, id string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM access_tokens WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+func (r *PostgresAccessTokenRepository) DeleteByUserID(ctx context.Context, userID string) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM access_tokens WHERE user_id = $1`, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/models"
+)
+
+// MemoryAuditRepository - журнал безопасности в памяти (для разработки и демо)
+type MemoryAuditRepository struct {
+	mu     sync.RWMutex
+	events []*models.AuditEvent // по возрастанию ID
+}
+
+func NewMemoryAuditRepository() *MemoryAuditRepository {
+	return &MemoryAuditRepository{}
+}
+
+func (r *MemoryAuditRepository) Append(ctx context.Context, event *models.AuditEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	event.ID = int64(len(r.events)) + 1
+	c := *event
+	c.Details = copyDetails(event.Details)
+	r.events = append(r.events, &c)
+	return nil
+}
+
+func (r *MemoryAuditRepository) List(ctx context.Context, filter AuditFilter) ([]*models.AuditEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var events []*models.AuditEvent
+	// ID совпадает с позицией в срезе, поэтому курсор - это просто смещение
+	start := int(filter.AfterID)
+	if start < 0 {
+		start = 0
+	}
+	for i := start; i < len(r.events); i++ {
+		e := r.events[i]
+		if filter.Type != "" && e.Type != filter.Type {
+			continue
+		}
+		if filter.Actor != "" && e.Actor != filter.Actor {
+			continue
+		}
+		if !filter.Since.IsZero() && e.Time.Before(filter.Since) {
+			continue
+		}
+		if !filter.Until.IsZero() && !e.Time.Before(filter.Until) {
+			continue
+		}
+		c := *e
+		c.Details = copyDetails(e.Details)
+		events = append(events, &c)
+		if filter.Limit > 0 && len(events) == filter.Limit {
+			break
+		}
+	}
+	return events, nil
+}
+
+func copyDetails(details map[string]string) map[string]string {
+	if details == nil {
+		return nil
+	}
+	c := make(map[string]string, len(details))
+	for k, v := range details {
+		c[k] = v
+	}
+	return c
+}
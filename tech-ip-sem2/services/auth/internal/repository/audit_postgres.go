@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/models"
+)
+
+type PostgresAuditRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresAuditRepository(db *sql.DB) *PostgresAuditRepository {
+	return &PostgresAuditRepository{db: db}
+}
+
+func (r *PostgresAuditRepository) Append(ctx context.Context, event *models.AuditEvent) error {
+	details, err := json.Marshal(event.Details)
+	if err != nil {
+		return err
+	}
+	query := `INSERT INTO audit_events (time, type, actor, actor_id, ip, user_agent, request_id, details)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`
+	return r.db.QueryRowContext(ctx, query,
+		event.Time, event.Type, event.Actor, event.ActorID, event.IP, event.UserAgent, event.RequestID, details,
+	).Scan(&event.ID)
+}
+
+func (r *PostgresAuditRepository) List(ctx context.Context, filter AuditFilter) ([]*models.AuditEvent, error) {
+	var (
+		conds = []string{"id > $1"}
+		args  = []any{filter.AfterID}
+	)
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if filter.Type != "" {
+		add("type = $%d", filter.Type)
+	}
+	if filter.Actor != "" {
+		add("actor = $%d", filter.Actor)
+	}
+	if !filter.Since.IsZero() {
+		add("time >= $%d", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		add("time < $%d", filter.Until)
+	}
+
+	query := `SELECT id, time, type, actor, actor_id, ip, user_agent, request_id, details
+              FROM audit_events WHERE ` + strings.Join(conds, " AND ") + ` ORDER BY id`
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*models.AuditEvent
+	for rows.Next() {
+		e := &models.AuditEvent{}
+		var details []byte
+		if err := rows.Scan(&e.ID, &e.Time, &e.Type, &e.Actor, &e.ActorID,
+			&e.IP, &e.UserAgent, &e.RequestID, &details); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(details, &e.Details); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/models"
+)
+
+// MemoryIdentityRepository - привязки к внешним провайдерам в памяти (для разработки и демо)
+type MemoryIdentityRepository struct {
+	mu         sync.RWMutex
+	identities map[string]*models.UserIdentity // issuer + " " + subject -> identity
+}
+
+func NewMemoryIdentityRepository() *MemoryIdentityRepository {
+	return &MemoryIdentityRepository{identities: make(map[string]*models.UserIdentity)}
+}
+
+func identityKey(issuer, subject string) string {
+	return issuer + " " + subject
+}
+
+func (r *MemoryIdentityRepository) Create(ctx context.Context, identity *models.UserIdentity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := identityKey(identity.Issuer, identity.Subject)
+	if _, ok := r.identities[key]; ok {
+		return ErrAlreadyExists
+	}
+	c := *identity
+	r.identities[key] = &c
+	return nil
+}
+
+func (r *MemoryIdentityRepository) Get(ctx context.Context, issuer, subject string) (*models.UserIdentity, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	identity, ok := r.identities[identityKey(issuer, subject)]
+	if !ok {
+		return nil, nil
+	}
+	c := *identity
+	return &c, nil
+}
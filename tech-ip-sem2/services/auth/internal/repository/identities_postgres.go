@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/models"
+)
+
+type PostgresIdentityRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresIdentityRepository(db *sql.DB) *PostgresIdentityRepository {
+	return &PostgresIdentityRepository{db: db}
+}
+
+func (r *PostgresIdentityRepository) Create(ctx context.Context, identity *models.UserIdentity) error {
+	query := `INSERT INTO user_identities (issuer, subject, user_id, email, created_at)
+              VALUES ($1, $2, $3, NULLIF($4, ''), $5)`
+	_, err := r.db.ExecContext(ctx, query,
+		identity.Issuer, identity.Subject, identity.UserID, identity.Email, identity.CreatedAt)
+	if isUniqueViolation(err) {
+		return ErrAlreadyExists
+	}
+	return err
+}
+
+func (r *PostgresIdentityRepository) Get(ctx context.Context, issuer, subject string) (*models.UserIdentity, error) {
+	query := `SELECT issuer, subject, user_id, COALESCE(email, ''), created_at
+              FROM user_identities WHERE issuer = $1 AND subject = $2`
+	identity := &models.UserIdentity{}
+	err := r.db.QueryRowContext(ctx, query, issuer, subject).Scan(
+		&identity.Issuer, &identity.Subject, &identity.UserID, &identity.Email, &identity.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
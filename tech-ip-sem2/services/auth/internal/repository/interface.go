@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/models"
+)
+
+// ErrAlreadyExists возвращается при нарушении уникальности (username, email)
+var ErrAlreadyExists = errors.New("already exists")
+
+// UserRepository - хранилище пользователей.
+// Методы Get* возвращают (nil, nil), если пользователь не найден.
+type UserRepository interface {
+	Create(ctx context.Context, user *models.User) error
+	GetByID(ctx context.Context, id string) (*models.User, error)
+	GetByUsername(ctx context.Context, username string) (*models.User, error)
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	// Update сохраняет изменяемые поля; sql.ErrNoRows, если пользователя нет.
+	// Шаг TOTP не уменьшается, чтобы устаревшая копия пользователя не открыла повтор кода.
+	Update(ctx context.Context, user *models.User) error
+	// AdvanceTOTPStep атомарно запоминает принятый шаг TOTP, если он больше сохранённого;
+	// false - шаг уже использован (повтор кода)
+	AdvanceTOTPStep(ctx context.Context, userID string, step int64) (bool, error)
+	// ConsumeRecoveryCode атомарно удаляет хеш кода восстановления; false - кода нет
+	ConsumeRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error)
+	// SetTOTPSecret сохраняет секрет подключаемой 2FA, остальные поля не меняются;
+	// false - 2FA уже включена (её секрет не перезаписывается)
+	SetTOTPSecret(ctx context.Context, userID, secret string) (bool, error)
+	// EnableTOTP включает 2FA с секретом secret и сохраняет хеши кодов восстановления;
+	// false - 2FA уже включена или секрет с тех пор заменён
+	EnableTOTP(ctx context.Context, userID, secret string, step int64, recoveryCodes []string) (bool, error)
+}
+
+// SessionRepository - хранилище серверных сессий.
+// GetByTokenHash возвращает (nil, nil), если сессия не найдена.
+type SessionRepository interface {
+	Create(ctx context.Context, session *models.Session) error
+	GetByTokenHash(ctx context.Context, tokenHash string) (*models.Session, error)
+	// ListByUserID возвращает сессии пользователя, новые первыми
+	ListByUserID(ctx context.Context, userID string) ([]*models.Session, error)
+	Touch(ctx context.Context, id string, lastSeenAt, expiresAt time.Time) error
+	Delete(ctx context.Context, id string) error
+	// DeleteForUser удаляет сессию, только если она принадлежит userID; false - не найдена
+	DeleteForUser(ctx context.Context, userID, id string) (bool, error)
+	DeleteByUserID(ctx context.Context, userID string) (int64, error)
+	// DeleteOthers удаляет все сессии пользователя, кроме keepID
+	DeleteOthers(ctx context.Context, userID, keepID string) (int64, error)
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+	// CountActive возвращает число сессий, не истёкших к моменту now
+	CountActive(ctx context.Context, now time.Time) (int64, error)
+}
+
+// RefreshTokenRepository - хранилище refresh-токенов (хранятся только хеши).
+// GetByTokenHash возвращает (nil, nil), если токен не найден.
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *models.RefreshToken) error
+	GetByTokenHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error)
+	// MarkUsed атомарно помечает токен использованным; false - токен уже был использован
+	MarkUsed(ctx context.Context, id string, usedAt time.Time) (bool, error)
+	RevokeFamily(ctx context.Context, familyID string, revokedAt time.Time) error
+	RevokeByUserID(ctx context.Context, userID string, revokedAt time.Time) error
+}
+
+// PasswordResetRepository - токены сброса пароля (хранятся только хеши).
+// GetByTokenHash возвращает (nil, nil), если токен не найден.
+type PasswordResetRepository interface {
+	Create(ctx context.Context, reset *models.PasswordReset) error
+	GetByTokenHash(ctx context.Context, tokenHash string) (*models.PasswordReset, error)
+	// MarkUsed атомарно помечает токен использованным; false - токен уже был использован
+	MarkUsed(ctx context.Context, id string, usedAt time.Time) (bool, error)
+	// DeleteByUserID удаляет все токены пользователя (выданные ранее перестают действовать)
+	DeleteByUserID(ctx context.Context, userID string) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// AccessTokenRepository - персональные токены доступа (хранятся только хеши).
+// GetByTokenHash возвращает (nil, nil), если токен не найден.
+type AccessTokenRepository interface {
+	Create(ctx context.Context, token *models.AccessToken) error
+	GetByTokenHash(ctx context.Context, tokenHash string) (*models.AccessToken, error)
+	ListByUserID(ctx context.Context, userID string) ([]*models.AccessToken, error)
+	TouchLastUsed(ctx context.Context, id string, lastUsedAt time.Time) error
+	// Delete удаляет токен пользователя; false - такого токена у пользователя нет
+	Delete(ctx context.Context, userID, id string) (bool, error)
+	// DeleteByUserID удаляет все токены пользователя и возвращает их количество
+	DeleteByUserID(ctx context.Context, userID string) (int64, error)
+}
+
+// LoginAttemptStore - счётчики неудачных попыток входа.
+// Get возвращает (nil, nil), если неудач по ключу не было.
+type LoginAttemptStore interface {
+	Get(ctx context.Context, key string) (*models.LoginAttempts, error)
+	// RegisterFailure атомарно увеличивает счётчик; счётчик, не обновлявшийся дольше window, начинается заново
+	RegisterFailure(ctx context.Context, key string, now time.Time, window time.Duration) (*models.LoginAttempts, error)
+	Reset(ctx context.Context, key string) error
+	DeleteStale(ctx context.Context, before time.Time) (int64, error)
+}
+
+// MFAChallengeStore - незавершённые двухшаговые входы.
+// Get возвращает (nil, nil), если вызов не найден.
+type MFAChallengeStore interface {
+	Create(ctx context.Context, challenge *models.MFAChallenge) error
+	Get(ctx context.Context, id string) (*models.MFAChallenge, error)
+	// IncrementAttempts увеличивает счётчик попыток и возвращает новое значение
+	IncrementAttempts(ctx context.Context, id string) (int, error)
+	Delete(ctx context.Context, id string) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// OIDCLoginStore - начатые входы через внешнего провайдера (по state).
+// Take возвращает и сразу удаляет запись (state одноразовый); (nil, nil), если не найдена.
+type OIDCLoginStore interface {
+	Create(ctx context.Context, login *models.OIDCLogin) error
+	Take(ctx context.Context, state string) (*models.OIDCLogin, error)
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// IdentityRepository - привязки пользователей к внешним провайдерам.
+// Get возвращает (nil, nil), если привязки нет.
+type IdentityRepository interface {
+	Create(ctx context.Context, identity *models.UserIdentity) error
+	Get(ctx context.Context, issuer, subject string) (*models.UserIdentity, error)
+}
+
+// AuditFilter - условия выборки журнала безопасности; пустые поля не ограничивают выборку
+type AuditFilter struct {
+	Type    string
+	Actor   string
+	Since   time.Time // включительно
+	Until   time.Time // не включительно
+	AfterID int64     // курсор: только записи с ID больше указанного
+	Limit   int
+}
+
+// AuditRepository - журнал безопасности, только добавление.
+// List возвращает записи в порядке возрастания ID.
+type AuditRepository interface {
+	Append(ctx context.Context, event *models.AuditEvent) error
+	List(ctx context.Context, filter AuditFilter) ([]*models.AuditEvent, error)
+}
@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/models"
+)
+
+// MemoryLoginAttemptStore - счётчики попыток входа в памяти (хранилище по умолчанию)
+type MemoryLoginAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string]*models.LoginAttempts
+}
+
+func NewMemoryLoginAttemptStore() *MemoryLoginAttemptStore {
+	return &MemoryLoginAttemptStore{attempts: make(map[string]*models.LoginAttempts)}
+}
+
+func (s *MemoryLoginAttemptStore) Get(ctx context.Context, key string) (*models.LoginAttempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.attempts[key]
+	if !ok {
+		return nil, nil
+	}
+	c := *a
+	return &c, nil
+}
+
+func (s *MemoryLoginAttemptStore) RegisterFailure(ctx context.Context, key string, now time.Time, window time.Duration) (*models.LoginAttempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.attempts[key]
+	if !ok || now.Sub(a.LastFailureAt) > window {
+		a = &models.LoginAttempts{}
+		s.attempts[key] = a
+	}
+	a.Failures++
+	a.LastFailureAt = now
+	c := *a
+	return &c, nil
+}
+
+func (s *MemoryLoginAttemptStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.attempts, key)
+	return nil
+}
+
+func (s *MemoryLoginAttemptStore) DeleteStale(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var n int64
+	for key, a := range s.attempts {
+		if a.LastFailureAt.Before(before) {
+			delete(s.attempts, key)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// MemoryMFAChallengeStore - незавершённые двухшаговые входы в памяти
+type MemoryMFAChallengeStore struct {
+	mu         sync.Mutex
+	challenges map[string]*models.MFAChallenge
+}
+
+func NewMemoryMFAChallengeStore() *MemoryMFAChallengeStore {
+	return &MemoryMFAChallengeStore{challenges: make(map[string]*models.MFAChallenge)}
+}
+
+func (s *MemoryMFAChallengeStore) Create(ctx context.Context, challenge *models.MFAChallenge) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.challenges[challenge.ID]; ok {
+		return ErrAlreadyExists
+	}
+	c := *challenge
+	s.challenges[challenge.ID] = &c
+	return nil
+}
+
+func (s *MemoryMFAChallengeStore) Get(ctx context.Context, id string) (*models.MFAChallenge, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ch, ok := s.challenges[id]
+	if !ok {
+		return nil, nil
+	}
+	c := *ch
+	return &c, nil
+}
+
+func (s *MemoryMFAChallengeStore) IncrementAttempts(ctx context.Context, id string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ch, ok := s.challenges[id]
+	if !ok {
+		return 0, nil
+	}
+	ch.Attempts++
+	return ch.Attempts, nil
+}
+
+func (s *MemoryMFAChallengeStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.challenges, id)
+	return nil
+}
+
+func (s *MemoryMFAChallengeStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var n int64
+	for id, ch := range s.challenges {
+		if !ch.ExpiresAt.After(now) {
+			delete(s.challenges, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// MemoryOIDCLoginStore - начатые входы через внешнего провайдера в памяти
+type MemoryOIDCLoginStore struct {
+	mu     sync.Mutex
+	logins map[string]*models.OIDCLogin
+}
+
+func NewMemoryOIDCLoginStore() *MemoryOIDCLoginStore {
+	return &MemoryOIDCLoginStore{logins: make(map[string]*models.OIDCLogin)}
+}
+
+func (s *MemoryOIDCLoginStore) Create(ctx context.Context, login *models.OIDCLogin) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.logins[login.State]; ok {
+		return ErrAlreadyExists
+	}
+	c := *login
+	s.logins[login.State] = &c
+	return nil
+}
+
+func (s *MemoryOIDCLoginStore) Take(ctx context.Context, state string) (*models.OIDCLogin, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	login, ok := s.logins[state]
+	if !ok {
+		return nil, nil
+	}
+	delete(s.logins, state)
+	return login, nil
+}
+
+func (s *MemoryOIDCLoginStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var n int64
+	for state, login := range s.logins {
+		if !login.ExpiresAt.After(now) {
+			delete(s.logins, state)
+			n++
+		}
+	}
+	return n, nil
+}
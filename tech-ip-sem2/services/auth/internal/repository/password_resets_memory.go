@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/models"
+)
+
+// MemoryPasswordResetRepository - хранилище токенов сброса пароля в памяти (для разработки и демо)
+type MemoryPasswordResetRepository struct {
+	mu     sync.Mutex
+	resets map[string]*models.PasswordReset // token hash -> reset
+}
+
+func NewMemoryPasswordResetRepository() *MemoryPasswordResetRepository {
+	return &MemoryPasswordResetRepository{resets: make(map[string]*models.PasswordReset)}
+}
+
+func (r *MemoryPasswordResetRepository) Create(ctx context.Context, reset *models.PasswordReset) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.resets[reset.TokenHash]; ok {
+		return ErrAlreadyExists
+	}
+	c := *reset
+	r.resets[reset.TokenHash] = &c
+	return nil
+}
+
+func (r *MemoryPasswordResetRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.PasswordReset, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	reset, ok := r.resets[tokenHash]
+	if !ok {
+		return nil, nil
+	}
+	c := *reset
+	return &c, nil
+}
+
+func (r *MemoryPasswordResetRepository) MarkUsed(ctx context.Context, id string, usedAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, reset := range r.resets {
+		if reset.ID == id {
+			if reset.UsedAt != nil {
+				return false, nil
+			}
+			reset.UsedAt = &usedAt
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *MemoryPasswordResetRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for hash, reset := range r.resets {
+		if reset.UserID == userID {
+			delete(r.resets, hash)
+		}
+	}
+	return nil
+}
+
+func (r *MemoryPasswordResetRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var n int64
+	for hash, reset := range r.resets {
+		if !reset.ExpiresAt.After(now) {
+			delete(r.resets, hash)
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/models"
+)
+
+type PostgresPasswordResetRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresPasswordResetRepository(db *sql.DB) *PostgresPasswordResetRepository {
+	return &PostgresPasswordResetRepository{db: db}
+}
+
+func (r *PostgresPasswordResetRepository) Create(ctx context.Context, reset *models.PasswordReset) error {
+	query := `INSERT INTO password_resets (id, user_id, token_hash, created_at, expires_at)
+              VALUES ($1, $2, $3, $4, $5)`
+	_, err := r.db.ExecContext(ctx, query,
+		reset.ID, reset.UserID, reset.TokenHash, reset.CreatedAt, reset.ExpiresAt)
+	return err
+}
+
+func (r *PostgresPasswordResetRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.PasswordReset, error) {
+	query := `SELECT id, user_id, token_hash, created_at, expires_at, used_at
+              FROM password_resets WHERE token_hash = $1`
+	reset := &models.PasswordReset{}
+	var usedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, query, tokenHash).Scan(
+		&reset.ID, &reset.UserID, &reset.TokenHash, &reset.CreatedAt, &reset.ExpiresAt, &usedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if usedAt.Valid {
+		reset.UsedAt = &usedAt.Time
+	}
+	return reset, nil
+}
+
+func (r *PostgresPasswordResetRepository) MarkUsed(ctx context.Context, id string, usedAt time.Time) (bool, error) {
+	query := `UPDATE password_resets SET used_at = $1 WHERE id = $2 AND used_at IS NULL`
+	result, err := r.db.ExecContext(ctx, query, usedAt, id)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+func (r *PostgresPasswordResetRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM password_resets WHERE user_id = $1`, userID)
+	return err
+}
+
+func (r *PostgresPasswordResetRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM password_resets WHERE expires_at <= $1`, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/models"
+)
+
+// MemoryRefreshTokenRepository - хранилище refresh-токенов в памяти (для разработки и демо)
+type MemoryRefreshTokenRepository struct {
+	mu     sync.Mutex
+	tokens map[string]*models.RefreshToken // token hash -> token
+}
+
+func NewMemoryRefreshTokenRepository() *MemoryRefreshTokenRepository {
+	return &MemoryRefreshTokenRepository{tokens: make(map[string]*models.RefreshToken)}
+}
+
+func (r *MemoryRefreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tokens[token.TokenHash]; ok {
+		return ErrAlreadyExists
+	}
+	c := *token
+	r.tokens[token.TokenHash] = &c
+	return nil
+}
+
+func (r *MemoryRefreshTokenRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.tokens[tokenHash]
+	if !ok {
+		return nil, nil
+	}
+	c := *t
+	return &c, nil
+}
+
+func (r *MemoryRefreshTokenRepository) MarkUsed(ctx context.Context, id string, usedAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, t := range r.tokens {
+		if t.ID == id {
+			if t.UsedAt != nil {
+				return false, nil
+			}
+			t.UsedAt = &usedAt
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *MemoryRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string, revokedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, t := range r.tokens {
+		if t.FamilyID == familyID && t.RevokedAt == nil {
+			at := revokedAt
+			t.RevokedAt = &at
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRefreshTokenRepository) RevokeByUserID(ctx context.Context, userID string, revokedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, t := range r.tokens {
+		if t.UserID == userID && t.RevokedAt == nil {
+			at := revokedAt
+			t.RevokedAt = &at
+		}
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/models"
+)
+
+// MemorySessionRepository - хранилище сессий в памяти (для разработки и демо)
+type MemorySessionRepository struct {
+	mu       sync.RWMutex
+	sessions map[string]*models.Session // id -> session
+	byToken  map[string]string          // token hash -> id
+}
+
+func NewMemorySessionRepository() *MemorySessionRepository {
+	return &MemorySessionRepository{
+		sessions: make(map[string]*models.Session),
+		byToken:  make(map[string]string),
+	}
+}
+
+func (r *MemorySessionRepository) Create(ctx context.Context, session *models.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.sessions[session.ID]; ok {
+		return ErrAlreadyExists
+	}
+	if _, ok := r.byToken[session.TokenHash]; ok {
+		return ErrAlreadyExists
+	}
+	c := *session
+	r.sessions[session.ID] = &c
+	r.byToken[session.TokenHash] = session.ID
+	return nil
+}
+
+func (r *MemorySessionRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id, ok := r.byToken[tokenHash]
+	if !ok {
+		return nil, nil
+	}
+	c := *r.sessions[id]
+	return &c, nil
+}
+
+func (r *MemorySessionRepository) ListByUserID(ctx context.Context, userID string) ([]*models.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var sessions []*models.Session
+	for _, s := range r.sessions {
+		if s.UserID == userID {
+			c := *s
+			sessions = append(sessions, &c)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt.After(sessions[j].CreatedAt)
+	})
+	return sessions, nil
+}
+
+func (r *MemorySessionRepository) Touch(ctx context.Context, id string, lastSeenAt, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if s, ok := r.sessions[id]; ok {
+		s.LastSeenAt = lastSeenAt
+		s.ExpiresAt = expiresAt
+	}
+	return nil
+}
+
+func (r *MemorySessionRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.deleteLocked(id)
+	return nil
+}
+
+func (r *MemorySessionRepository) DeleteForUser(ctx context.Context, userID, id string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.sessions[id]
+	if !ok || s.UserID != userID {
+		return false, nil
+	}
+	r.deleteLocked(id)
+	return true, nil
+}
+
+func (r *MemorySessionRepository) DeleteOthers(ctx context.Context, userID, keepID string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var n int64
+	for id, s := range r.sessions {
+		if s.UserID == userID && id != keepID {
+			r.deleteLocked(id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *MemorySessionRepository) DeleteByUserID(ctx context.Context, userID string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var n int64
+	for id, s := range r.sessions {
+		if s.UserID == userID {
+			r.deleteLocked(id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *MemorySessionRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var n int64
+	for id, s := range r.sessions {
+		if !s.ExpiresAt.After(now) {
+			r.deleteLocked(id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *MemorySessionRepository) CountActive(ctx context.Context, now time.Time) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var n int64
+	for _, s := range r.sessions {
+		if s.ExpiresAt.After(now) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *MemorySessionRepository) deleteLocked(id string) {
+	if s, ok := r.sessions[id]; ok {
+		delete(r.byToken, s.TokenHash)
+		delete(r.sessions, id)
+	}
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/models"
+)
+
+type PostgresSessionRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresSessionRepository(db *sql.DB) *PostgresSessionRepository {
+	return &PostgresSessionRepository{db: db}
+}
+
+const sessionColumns = `id, token_hash, user_id, created_at, last_seen_at, expires_at, ip, user_agent`
+
+func scanSession(row interface{ Scan(dest ...any) error }) (*models.Session, error) {
+	session := &models.Session{}
+	err := row.Scan(&session.ID, &session.TokenHash, &session.UserID,
+		&session.CreatedAt, &session.LastSeenAt, &session.ExpiresAt, &session.IP, &session.UserAgent)
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (r *PostgresSessionRepository) Create(ctx context.Context, session *models.Session) error {
+	query := `INSERT INTO sessions (` + sessionColumns + `)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	_, err := r.db.ExecContext(ctx, query,
+		session.ID, session.TokenHash, session.UserID, session.CreatedAt, session.LastSeenAt, session.ExpiresAt,
+		session.IP, session.UserAgent)
+	return err
+}
+
+func (r *PostgresSessionRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.Session, error) {
+	query := `SELECT ` + sessionColumns + ` FROM sessions WHERE token_hash = $1`
+	session, err := scanSession(r.db.QueryRowContext(ctx, query, tokenHash))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (r *PostgresSessionRepository) ListByUserID(ctx context.Context, userID string) ([]*models.Session, error) {
+	query := `SELECT ` + sessionColumns + ` FROM sessions WHERE user_id = $1 ORDER BY created_at DESC`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []*models.Session
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+func (r *PostgresSessionRepository) Touch(ctx context.Context, id string, lastSeenAt, expiresAt time.Time) error {
+	query := `UPDATE sessions SET last_seen_at = $1, expires_at = $2 WHERE id = $3`
+	_, err := r.db.ExecContext(ctx, query, lastSeenAt, expiresAt, id)
+	return err
+}
+
+func (r *PostgresSessionRepository) Delete(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM sessions WHERE id = $1`, id)
+	return err
+}
+
+func (r *PostgresSessionRepository) DeleteForUser(ctx context.Context, userID, id string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM sessions WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+func (r *PostgresSessionRepository) DeleteOthers(ctx context.Context, userID, keepID string) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM sessions WHERE user_id = $1 AND id <> $2`, userID, keepID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (r *PostgresSessionRepository) DeleteByUserID(ctx context.Context, userID string) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM sessions WHERE user_id = $1`, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (r *PostgresSessionRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM sessions WHERE expires_at <= $1`, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (r *PostgresSessionRepository) CountActive(ctx context.Context, now time.Time) (int64, error) {
+	var n int64
+	err := r.db.QueryRowContext(ctx, `SELECT count(*) FROM sessions WHERE expires_at > $1`, now).Scan(&n)
+	return n, err
+}
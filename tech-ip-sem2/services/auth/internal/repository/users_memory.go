@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/models"
+)
+
+// MemoryUserRepository - хранилище пользователей в памяти (для разработки и демо)
+type MemoryUserRepository struct {
+	mu    sync.RWMutex
+	users map[string]*models.User // id -> user
+}
+
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{users: make(map[string]*models.User)}
+}
+
+func (r *MemoryUserRepository) Create(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, u := range r.users {
+		if u.ID == user.ID || u.Username == user.Username || (user.Email != "" && u.Email == user.Email) {
+			return ErrAlreadyExists
+		}
+	}
+	r.users[user.ID] = copyUser(user)
+	return nil
+}
+
+func (r *MemoryUserRepository) GetByID(ctx context.Context, id string) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if u, ok := r.users[id]; ok {
+		return copyUser(u), nil
+	}
+	return nil, nil
+}
+
+func (r *MemoryUserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, u := range r.users {
+		if u.Username == username {
+			return copyUser(u), nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *MemoryUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, u := range r.users {
+		if email != "" && u.Email == email {
+			return copyUser(u), nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *MemoryUserRepository) Update(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[user.ID]; !ok {
+		return sql.ErrNoRows
+	}
+	for _, u := range r.users {
+		if u.ID != user.ID && user.Email != "" && u.Email == user.Email {
+			return ErrAlreadyExists
+		}
+	}
+	c := copyUser(user)
+	c.TOTPLastStep = max(c.TOTPLastStep, r.users[user.ID].TOTPLastStep)
+	c.UpdatedAt = time.Now()
+	r.users[user.ID] = c
+	return nil
+}
+
+func (r *MemoryUserRepository) AdvanceTOTPStep(ctx context.Context, userID string, step int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[userID]
+	if !ok || u.TOTPLastStep >= step {
+		return false, nil
+	}
+	u.TOTPLastStep = step
+	u.UpdatedAt = time.Now()
+	return true, nil
+}
+
+func (r *MemoryUserRepository) ConsumeRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[userID]
+	if !ok {
+		return false, nil
+	}
+	for i, stored := range u.RecoveryCodes {
+		if stored == codeHash {
+			u.RecoveryCodes = append(u.RecoveryCodes[:i:i], u.RecoveryCodes[i+1:]...)
+			u.UpdatedAt = time.Now()
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *MemoryUserRepository) SetTOTPSecret(ctx context.Context, userID, secret string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[userID]
+	if !ok || u.TOTPEnabled {
+		return false, nil
+	}
+	u.TOTPSecret = secret
+	u.UpdatedAt = time.Now()
+	return true, nil
+}
+
+func (r *MemoryUserRepository) EnableTOTP(ctx context.Context, userID, secret string, step int64, recoveryCodes []string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[userID]
+	if !ok || u.TOTPEnabled || u.TOTPSecret != secret {
+		return false, nil
+	}
+	u.TOTPEnabled = true
+	u.TOTPLastStep = max(u.TOTPLastStep, step)
+	u.RecoveryCodes = append([]string(nil), recoveryCodes...)
+	u.UpdatedAt = time.Now()
+	return true, nil
+}
+
+// copyUser защищает данные хранилища от изменения вызывающим кодом
+func copyUser(u *models.User) *models.User {
+	c := *u
+	c.Roles = append([]string(nil), u.Roles...)
+	c.RecoveryCodes = append([]string(nil), u.RecoveryCodes...)
+	return &c
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/models"
+)
+
+type PostgresUserRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresUserRepository(db *sql.DB) *PostgresUserRepository {
+	return &PostgresUserRepository{db: db}
+}
+
+// email необязателен: пустая строка хранится как NULL, чтобы не нарушать UNIQUE
+const userColumns = `id, username, COALESCE(email, ''), password_hash, roles, created_at, updated_at,
+	COALESCE(totp_secret, ''), totp_enabled, totp_last_step, recovery_codes`
+
+func scanUser(row interface{ Scan(...any) error }) (*models.User, error) {
+	user := &models.User{}
+	err := row.Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash,
+		pq.Array(&user.Roles), &user.CreatedAt, &user.UpdatedAt,
+		&user.TOTPSecret, &user.TOTPEnabled, &user.TOTPLastStep, pq.Array(&user.RecoveryCodes))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (r *PostgresUserRepository) Create(ctx context.Context, user *models.User) error {
+	query := `INSERT INTO users (id, username, email, password_hash, roles, created_at, updated_at)
+              VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7)`
+	_, err := r.db.ExecContext(ctx, query,
+		user.ID, user.Username, user.Email, user.PasswordHash,
+		pq.Array(user.Roles), user.CreatedAt, user.UpdatedAt)
+	if isUniqueViolation(err) {
+		return ErrAlreadyExists
+	}
+	return err
+}
+
+func (r *PostgresUserRepository) GetByID(ctx context.Context, id string) (*models.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1`
+	return scanUser(r.db.QueryRowContext(ctx, query, id))
+}
+
+func (r *PostgresUserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE username = $1`
+	return scanUser(r.db.QueryRowContext(ctx, query, username))
+}
+
+func (r *PostgresUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE email = $1`
+	return scanUser(r.db.QueryRowContext(ctx, query, email))
+}
+
+func (r *PostgresUserRepository) Update(ctx context.Context, user *models.User) error {
+	query := `UPDATE users SET email = NULLIF($1, ''), password_hash = $2, roles = $3,
+              totp_secret = NULLIF($4, ''), totp_enabled = $5, totp_last_step = GREATEST(totp_last_step, $6), recovery_codes = $7,
+              updated_at = NOW()
+              WHERE id = $8`
+	result, err := r.db.ExecContext(ctx, query,
+		user.Email, user.PasswordHash, pq.Array(user.Roles),
+		user.TOTPSecret, user.TOTPEnabled, user.TOTPLastStep, pq.Array(user.RecoveryCodes),
+		user.ID)
+	if isUniqueViolation(err) {
+		return ErrAlreadyExists
+	}
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *PostgresUserRepository) AdvanceTOTPStep(ctx context.Context, userID string, step int64) (bool, error) {
+	query := `UPDATE users SET totp_last_step = $1, updated_at = NOW() WHERE id = $2 AND totp_last_step < $1`
+	return r.execOne(ctx, query, step, userID)
+}
+
+func (r *PostgresUserRepository) ConsumeRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error) {
+	query := `UPDATE users SET recovery_codes = array_remove(recovery_codes, $1), updated_at = NOW()
+              WHERE id = $2 AND $1 = ANY(recovery_codes)`
+	return r.execOne(ctx, query, codeHash, userID)
+}
+
+func (r *PostgresUserRepository) SetTOTPSecret(ctx context.Context, userID, secret string) (bool, error) {
+	query := `UPDATE users SET totp_secret = $1, updated_at = NOW() WHERE id = $2 AND NOT totp_enabled`
+	return r.execOne(ctx, query, secret, userID)
+}
+
+func (r *PostgresUserRepository) EnableTOTP(ctx context.Context, userID, secret string, step int64, recoveryCodes []string) (bool, error) {
+	query := `UPDATE users SET totp_enabled = TRUE, totp_last_step = GREATEST(totp_last_step, $1),
+              recovery_codes = $2, updated_at = NOW()
+              WHERE id = $3 AND NOT totp_enabled AND totp_secret = $4`
+	return r.execOne(ctx, query, step, pq.Array(recoveryCodes), userID, secret)
+}
+
+// execOne выполняет условный UPDATE; false - условие не выполнилось
+func (r *PostgresUserRepository) execOne(ctx context.Context, query string, args ...any) (bool, error) {
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/metrics"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/models"
+)
+
+// Разрешения, которые можно выдать персональному токену
+const (
+	ScopeTasksRead  = "tasks:read"
+	ScopeTasksWrite = "tasks:write"
+)
+
+// AllScopes - полный набор разрешений (у сессии и JWT, полученного по паролю)
+var AllScopes = []string{ScopeTasksRead, ScopeTasksWrite}
+
+// accessTokenPrefix отличает персональные токены от JWT и помогает находить их в логах и секрет-сканерах
+const accessTokenPrefix = "pat_"
+
+const (
+	maxAccessTokenTTL     = 365 * 24 * time.Hour
+	maxAccessTokenNameLen = 64
+	// accessTokenTouchInterval ограничивает частоту записи last_used_at
+	accessTokenTouchInterval = time.Minute
+)
+
+var ErrAccessTokenNotFound = errors.New("access token not found")
+
+// TokenInfo - результат проверки Bearer-токена
+type TokenInfo struct {
+	Subject     string
+	Roles       []string
+	Permissions []string
+	Scopes      []string
+	ExpiresAt   time.Time
+}
+
+// CreateAccessToken выпускает персональный токен. Значение токена возвращается
+// только здесь, в хранилище попадает хеш. Ошибки валидации - *ValidationError.
+func (s *AuthService) CreateAccessToken(ctx context.Context, user *models.User, name string, scopes []string, ttl time.Duration) (string, *models.AccessToken, error) {
+	name = strings.TrimSpace(name)
+	scopes = normalizeList(scopes)
+
+	verr := &ValidationError{}
+	if name == "" {
+		verr.add("name", "is required")
+	} else if len(name) > maxAccessTokenNameLen {
+		verr.add("name", "must be at most 64 characters long")
+	}
+	if len(scopes) == 0 {
+		verr.add("scopes", "at least one scope is required")
+	}
+	for _, scope := range scopes {
+		if !validScope(scope) {
+			verr.add("scopes", "unknown scope "+scope)
+		}
+	}
+	if ttl <= 0 || ttl > maxAccessTokenTTL {
+		verr.add("expires_in_days", "must be between 1 and 365")
+	}
+	if len(verr.Fields) > 0 {
+		return "", nil, verr
+	}
+
+	secret, err := generateToken()
+	if err != nil {
+		return "", nil, err
+	}
+	token := accessTokenPrefix + secret
+
+	now := time.Now()
+	stored := &models.AccessToken{
+		ID:        "at_" + uuid.New().String(),
+		UserID:    user.ID,
+		Name:      name,
+		TokenHash: hashToken(token),
+		Scopes:    scopes,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+	if err := s.accessTokens.Create(ctx, stored); err != nil {
+		return "", nil, err
+	}
+	s.record(ctx, AuditAccessTokenCreated, user, "", map[string]string{
+		"token_id": stored.ID,
+		"scopes":   strings.Join(stored.Scopes, " "),
+	})
+	return token, stored, nil
+}
+
+// ListAccessTokens возвращает персональные токены пользователя (без значений)
+func (s *AuthService) ListAccessTokens(ctx context.Context, user *models.User) ([]*models.AccessToken, error) {
+	return s.accessTokens.ListByUserID(ctx, user.ID)
+}
+
+// RevokeAccessToken удаляет персональный токен пользователя
+func (s *AuthService) RevokeAccessToken(ctx context.Context, user *models.User, id string) error {
+	ok, err := s.accessTokens.Delete(ctx, user.ID, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrAccessTokenNotFound
+	}
+	s.revokeSubject(user)
+	s.record(ctx, AuditAccessTokenRevoked, user, "", map[string]string{"token_id": id})
+	return nil
+}
+
+// VerifyToken проверяет Bearer-токен: персональный (pat_...) или JWT access-токен.
+// Для недействительного токена возвращает (nil, nil).
+func (s *AuthService) VerifyToken(ctx context.Context, token string) (*TokenInfo, error) {
+	start := time.Now()
+	if strings.HasPrefix(token, accessTokenPrefix) {
+		info, err := s.verifyAccessToken(ctx, token)
+		metrics.TokenVerification(metrics.KindPAT, start, info != nil, err)
+		return info, err
+	}
+
+	claims, err := s.parseAccessToken(token)
+	metrics.TokenVerification(metrics.KindJWT, start, err == nil, nil)
+	if err != nil {
+		return nil, nil
+	}
+	return &TokenInfo{
+		Subject:     claims.Subject,
+		Roles:       claims.Roles,
+		Permissions: claims.Permissions,
+		Scopes:      claims.Scopes(),
+		ExpiresAt:   claims.ExpiresAt.Time,
+	}, nil
+}
+
+func (s *AuthService) verifyAccessToken(ctx context.Context, token string) (*TokenInfo, error) {
+	stored, err := s.accessTokens.GetByTokenHash(ctx, hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if stored == nil || !stored.ExpiresAt.After(now) {
+		return nil, nil
+	}
+
+	user, err := s.users.GetByID(ctx, stored.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, nil
+	}
+
+	if stored.LastUsedAt == nil || now.Sub(*stored.LastUsedAt) >= accessTokenTouchInterval {
+		if err := s.accessTokens.TouchLastUsed(ctx, stored.ID, now); err != nil {
+			return nil, err
+		}
+	}
+
+	return &TokenInfo{
+		Subject:     user.Username,
+		Roles:       user.Roles,
+		Permissions: Permissions(user.Roles),
+		Scopes:      stored.Scopes,
+		ExpiresAt:   stored.ExpiresAt,
+	}, nil
+}
+
+func validScope(scope string) bool {
+	return containsString(AllScopes, scope)
+}
+
+// normalizeList убирает пробелы, пустые значения и повторы, сохраняя порядок
+func normalizeList(scopes []string) []string {
+	seen := make(map[string]bool, len(scopes))
+	result := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if scope == "" || seen[scope] {
+			continue
+		}
+		seen[scope] = true
+		result = append(result, scope)
+	}
+	return result
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/models"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/repository"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/shared/middleware"
+)
+
+// Типы событий журнала безопасности
+const (
+	AuditLoginSucceeded       = "login.succeeded" // вход открыл сессию (регистрация не считается)
+	AuditLoginFailed          = "login.failed"
+	AuditLogout               = "logout"
+	AuditSessionRevoked       = "session.revoked"
+	AuditSessionsRevoked      = "sessions.revoked"
+	AuditUserRegistered       = "user.registered"
+	AuditRolesChanged         = "user.roles_changed"
+	AuditPasswordResetRequest = "password.reset_requested"
+	AuditPasswordChanged      = "password.changed"
+	AuditTOTPEnabled          = "totp.enabled"
+	AuditTokenIssued          = "token.issued"
+	AuditTokenRefreshed       = "token.refreshed"
+	AuditRefreshTokenReused   = "token.reuse_detected" // отозвано всё семейство
+	AuditAccessTokenCreated   = "access_token.created"
+	AuditAccessTokenRevoked   = "access_token.revoked"
+)
+
+// Способы входа в событиях login.succeeded
+const (
+	LoginMethodPassword = "password"
+	LoginMethodMFA      = "mfa" // второй фактор после пароля или внешнего провайдера
+	LoginMethodOIDC     = "oidc"
+)
+
+const (
+	defaultAuditPageSize = 100
+	maxAuditPageSize     = 1000
+)
+
+// Auditor пишет журнал безопасности. Ошибка записи не прерывает операцию
+// пользователя: событие целиком уходит в лог сервиса, чтобы не потеряться.
+type Auditor struct {
+	repo   repository.AuditRepository
+	logger *logrus.Logger
+}
+
+func NewAuditor(repo repository.AuditRepository, logger *logrus.Logger) *Auditor {
+	return &Auditor{repo: repo, logger: logger}
+}
+
+// Record дополняет событие временем, адресом клиента и request-id и сохраняет его
+func (a *Auditor) Record(ctx context.Context, event *models.AuditEvent) {
+	client := ClientInfoFromContext(ctx)
+	event.Time = time.Now().UTC()
+	event.IP = client.IP
+	event.UserAgent = truncateUserAgent(client.UserAgent)
+	event.RequestID = middleware.GetRequestID(ctx)
+
+	// Журнал не должен зависеть от отмены запроса клиентом
+	if err := a.repo.Append(context.WithoutCancel(ctx), event); err != nil {
+		a.logger.WithError(err).WithFields(logrus.Fields{
+			"component":  "audit",
+			"type":       event.Type,
+			"actor":      event.Actor,
+			"actor_id":   event.ActorID,
+			"ip":         event.IP,
+			"user_agent": event.UserAgent,
+			"request_id": event.RequestID,
+			"details":    event.Details,
+		}).Error("failed to write audit event")
+	}
+}
+
+// record - запись события от имени пользователя (user может быть nil, тогда actor - введённое имя)
+func (s *AuthService) record(ctx context.Context, eventType string, user *models.User, actor string, details map[string]string) {
+	event := &models.AuditEvent{
+		Type:    eventType,
+		Actor:   actor,
+		Details: details,
+	}
+	if user != nil {
+		event.Actor = user.Username
+		event.ActorID = user.ID
+	}
+	s.audit.Record(ctx, event)
+}
+
+// AuditEvents возвращает страницу журнала безопасности и курсор следующей страницы
+// (0, если записей больше нет). Доступно только с разрешением audit:read.
+func (s *AuthService) AuditEvents(ctx context.Context, actor *models.User, filter repository.AuditFilter) ([]*models.AuditEvent, int64, error) {
+	if !HasPermission(actor, PermAuditRead) {
+		return nil, 0, ErrForbidden
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditPageSize
+	}
+	if filter.Limit > maxAuditPageSize {
+		filter.Limit = maxAuditPageSize
+	}
+	events, err := s.audit.repo.List(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	var next int64
+	if len(events) == filter.Limit {
+		next = events[len(events)-1].ID
+	}
+	return events, next, nil
+}
+
+// ExportAuditEvents передаёт в fn все записи журнала, подходящие под фильтр, постранично.
+// Limit фильтра игнорируется. Доступно только с разрешением audit:read.
+func (s *AuthService) ExportAuditEvents(ctx context.Context, actor *models.User, filter repository.AuditFilter, fn func(*models.AuditEvent) error) error {
+	if !HasPermission(actor, PermAuditRead) {
+		return ErrForbidden
+	}
+	filter.Limit = maxAuditPageSize
+	for {
+		events, err := s.audit.repo.List(ctx, filter)
+		if err != nil {
+			return err
+		}
+		for _, e := range events {
+			if err := fn(e); err != nil {
+				return err
+			}
+		}
+		if len(events) < filter.Limit {
+			return nil
+		}
+		filter.AfterID = events[len(events)-1].ID
+	}
+}
@@ -1,31 +1,233 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"strings"
+	"time"
 
-	"github.com/sirupsen/logrus"
+	"github.com/google/uuid"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/mailer"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/metrics"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/models"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/oidc"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/repository"
 )
 
-// Logger экспортируется для использования в http-хендлерах
-var Logger *logrus.Logger
-
-const (
-	validUsername = "student"
-	validPassword = "student"
-	validToken    = "demo-token"
-	subject       = "student"
+var (
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrUsernameTaken      = errors.New("username already taken")
+	ErrEmailTaken         = errors.New("email already registered")
+	ErrUserNotFound       = errors.New("user not found")
 )
 
-func Login(username, password string) (string, error) {
-	if username == validUsername && password == validPassword {
-		return validToken, nil
+// Stores - хранилища, с которыми работает сервис
+type Stores struct {
+	Users          repository.UserRepository
+	Sessions       repository.SessionRepository
+	RefreshTokens  repository.RefreshTokenRepository
+	MFAChallenges  repository.MFAChallengeStore
+	PasswordResets repository.PasswordResetRepository
+	AccessTokens   repository.AccessTokenRepository
+	OIDCLogins     repository.OIDCLoginStore
+	Identities     repository.IdentityRepository
+}
+
+// Config - параметры сервиса
+type Config struct {
+	Session       SessionConfig
+	Token         TokenConfig
+	MFA           MFAConfig
+	PasswordReset PasswordResetConfig
+}
+
+type AuthService struct {
+	users          repository.UserRepository
+	sessions       repository.SessionRepository
+	refreshTokens  repository.RefreshTokenRepository
+	mfaChallenges  repository.MFAChallengeStore
+	passwordResets repository.PasswordResetRepository
+	accessTokens   repository.AccessTokenRepository
+	oidcLogins     repository.OIDCLoginStore
+	identities     repository.IdentityRepository
+	mailer         mailer.Mailer
+	keys           *KeyManager
+	guard          *LoginGuard
+	oidc           *oidc.Provider // nil - вход через внешнего провайдера выключен
+	audit          *Auditor
+	revocations    *revocationFeed
+	cfg            Config
+}
+
+func NewAuthService(stores Stores, keys *KeyManager, guard *LoginGuard, m mailer.Mailer, audit *Auditor, cfg Config) *AuthService {
+	return &AuthService{
+		users:          stores.Users,
+		sessions:       stores.Sessions,
+		refreshTokens:  stores.RefreshTokens,
+		mfaChallenges:  stores.MFAChallenges,
+		passwordResets: stores.PasswordResets,
+		accessTokens:   stores.AccessTokens,
+		oidcLogins:     stores.OIDCLogins,
+		identities:     stores.Identities,
+		mailer:         m,
+		keys:           keys,
+		guard:          guard,
+		audit:          audit,
+		revocations:    newRevocationFeed(),
+		cfg:            cfg,
+	}
+}
+
+// normalizeUsername приводит имя к каноническому виду (логин регистронезависимый)
+func normalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Login ищет пользователя и проверяет пароль.
+// После серии неудач по username или IP клиента возвращает *LockedError.
+// Для пользователя с 2FA вход завершается в CompleteMFAChallenge или IssueToken.
+func (s *AuthService) Login(ctx context.Context, username, password string) (*models.User, error) {
+	username = normalizeUsername(username)
+	ip := ClientInfoFromContext(ctx).IP
+
+	if err := s.guard.Check(ctx, username, ip); err != nil {
+		var locked *LockedError
+		if errors.As(err, &locked) {
+			metrics.LoginAttempt(metrics.LoginLocked)
+			s.record(ctx, AuditLoginFailed, nil, username, map[string]string{"reason": "locked"})
+		}
+		return nil, err
+	}
+
+	user, err := s.checkCredentials(ctx, username, password)
+	if errors.Is(err, ErrInvalidCredentials) {
+		metrics.LoginAttempt(metrics.LoginInvalidCredentials)
+		s.record(ctx, AuditLoginFailed, nil, username, map[string]string{"reason": "invalid_credentials"})
+		if ferr := s.guard.Failure(ctx, username, ip); ferr != nil {
+			return nil, ferr
+		}
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// При 2FA счётчик сбрасывается только после второго фактора, иначе
+	// знающий пароль мог бы перебирать TOTP-коды без ограничений
+	if !user.TOTPEnabled {
+		if err := s.guard.Success(ctx, username); err != nil {
+			return nil, err
+		}
 	}
-	return "", errors.New("invalid credentials")
+	return user, nil
 }
 
-func VerifyToken(token string) (bool, string) {
-	if token == validToken {
-		return true, subject
+func (s *AuthService) checkCredentials(ctx context.Context, username, password string) (*models.User, error) {
+	user, err := s.users.GetByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	// У пользователей, созданных при входе через провайдера, пароля нет
+	if user == nil || user.PasswordHash == "" {
+		checkDummyPassword(password)
+		return nil, ErrInvalidCredentials
+	}
+	if !CheckPassword(user.PasswordHash, password) {
+		return nil, ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+// Register регистрирует нового пользователя после проверки парольной политики и уникальности.
+// Ошибки валидации возвращаются как *ValidationError.
+func (s *AuthService) Register(ctx context.Context, username, email, password string) (*models.User, error) {
+	username = normalizeUsername(username)
+	email = normalizeEmail(email)
+
+	if verr := validateRegistration(username, email, password); verr != nil {
+		return nil, verr
+	}
+
+	existing, err := s.users.GetByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrUsernameTaken
+	}
+
+	existing, err = s.users.GetByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrEmailTaken
+	}
+
+	// При гонке между проверкой и вставкой уникальность гарантирует хранилище
+	// (вернётся repository.ErrAlreadyExists)
+	user, err := s.CreateUser(ctx, username, email, password, nil)
+	if err != nil {
+		return nil, err
+	}
+	s.record(ctx, AuditUserRegistered, user, "", map[string]string{"method": "password"})
+	return user, nil
+}
+
+func newUserID() string {
+	return "u_" + uuid.New().String()
+}
+
+// CreateUser создаёт пользователя с захешированным паролем
+func (s *AuthService) CreateUser(ctx context.Context, username, email, password string, roles []string) (*models.User, error) {
+	hash, err := HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	if len(roles) == 0 {
+		roles = []string{RoleUser}
+	}
+
+	now := time.Now()
+	user := &models.User{
+		ID:           newUserID(),
+		Username:     normalizeUsername(username),
+		Email:        normalizeEmail(email),
+		PasswordHash: hash,
+		Roles:        roles,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	if err := s.users.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// EnsureUser создаёт пользователя с заданными ролями, если его ещё нет
+// (используется для начальных пользователей). Роли существующего пользователя не меняются.
+// Имя и пароль проверяются той же политикой, что и при регистрации (*ValidationError).
+func (s *AuthService) EnsureUser(ctx context.Context, username, password string, roles []string) error {
+	username = normalizeUsername(username)
+	if verr := validateCredentials(username, password); verr != nil {
+		return verr
+	}
+
+	existing, err := s.users.GetByUsername(ctx, username)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return nil
+	}
+	_, err = s.CreateUser(ctx, username, "", password, roles)
+	if errors.Is(err, repository.ErrAlreadyExists) {
+		return nil
 	}
-	return false, ""
+	return err
 }
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/mailer"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/repository"
+)
+
+// newTestService - сервис на хранилищах в памяти; незаданные Users и Sessions создаются
+func newTestService(t *testing.T, stores Stores, m mailer.Mailer, cfg Config) (*AuthService, *repository.MemoryAuditRepository) {
+	t.Helper()
+	keys, err := NewKeyManager(time.Hour, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	if stores.Users == nil {
+		stores.Users = repository.NewMemoryUserRepository()
+	}
+	if stores.Sessions == nil {
+		stores.Sessions = repository.NewMemorySessionRepository()
+	}
+	audit := repository.NewMemoryAuditRepository()
+	return NewAuthService(stores, keys, NewLoginGuard(repository.NewMemoryLoginAttemptStore(), LoginGuardConfig{}),
+		m, NewAuditor(audit, logger), cfg), audit
+}
+
+func TestEnsureUserAppliesPasswordPolicy(t *testing.T) {
+	cases := []struct {
+		name     string
+		username string
+		password string
+		wantErr  bool
+	}{
+		{"valid", "alice", "Correct-Horse-42", false},
+		{"too short", "alice", "abc1", true},
+		{"no digit", "alice", "student-password", true},
+		{"contains username", "alice", "alice-2024-pass", true},
+		{"invalid username", "a", "Correct-Horse-42", true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, _ := newTestService(t, Stores{}, nil, Config{})
+			err := s.EnsureUser(context.Background(), tc.username, tc.password, []string{RoleUser})
+
+			var verr *ValidationError
+			if tc.wantErr != errors.As(err, &verr) {
+				t.Fatalf("EnsureUser error = %v, want validation error: %v", err, tc.wantErr)
+			}
+			user, _ := s.users.GetByUsername(context.Background(), tc.username)
+			if (user != nil) == tc.wantErr {
+				t.Fatalf("user created = %v, want %v", user != nil, !tc.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package service
+
+import "context"
+
+// ClientInfo - сведения о клиенте, от имени которого выполняется операция
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+type clientInfoKey struct{}
+
+// WithClientInfo сохраняет сведения о клиенте в контексте запроса
+func WithClientInfo(ctx context.Context, info ClientInfo) context.Context {
+	return context.WithValue(ctx, clientInfoKey{}, info)
+}
+
+// ClientInfoFromContext возвращает сведения о клиенте (пустые, если не заданы)
+func ClientInfoFromContext(ctx context.Context) ClientInfo {
+	info, _ := ctx.Value(clientInfoKey{}).(ClientInfo)
+	return info
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/shared/jwks"
+)
+
+type signingKey struct {
+	kid       string
+	private   ed25519.PrivateKey
+	createdAt time.Time
+	retiredAt time.Time // нулевое значение - ключ активен
+}
+
+// KeyManager хранит ключи подписи JWT (Ed25519) и ротирует их.
+// После ротации старый ключ больше не подписывает, но остаётся в JWKS,
+// пока не истекут выпущенные им токены.
+type KeyManager struct {
+	mu          sync.RWMutex
+	keys        []*signingKey // последний - активный
+	rotateEvery time.Duration
+	retainFor   time.Duration // сколько хранить выведенный ключ (не меньше TTL токена)
+}
+
+func NewKeyManager(rotateEvery, tokenTTL time.Duration) (*KeyManager, error) {
+	m := &KeyManager{
+		rotateEvery: rotateEvery,
+		retainFor:   tokenTTL + time.Minute, // запас на расхождение часов
+	}
+	if err := m.Rotate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Rotate выпускает новый активный ключ и выводит предыдущий из подписи
+func (m *KeyManager) Rotate() error {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.addKeyLocked(priv, time.Now())
+	return nil
+}
+
+// signingKey возвращает активный ключ, при необходимости выполняя плановую ротацию
+func (m *KeyManager) signingKey() (*signingKey, error) {
+	m.mu.RLock()
+	current := m.keys[len(m.keys)-1]
+	m.mu.RUnlock()
+
+	if !m.rotationDue(current, time.Now()) {
+		return current, nil
+	}
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Пока ждали блокировку, ключ мог сменить параллельный вызов: ротируем только устаревший
+	now := time.Now()
+	if current = m.keys[len(m.keys)-1]; m.rotationDue(current, now) {
+		current = m.addKeyLocked(priv, now)
+	}
+	return current, nil
+}
+
+func (m *KeyManager) rotationDue(k *signingKey, now time.Time) bool {
+	return m.rotateEvery > 0 && now.Sub(k.createdAt) >= m.rotateEvery
+}
+
+// addKeyLocked делает priv активным ключом
+func (m *KeyManager) addKeyLocked(priv ed25519.PrivateKey, now time.Time) *signingKey {
+	if n := len(m.keys); n > 0 {
+		m.keys[n-1].retiredAt = now
+	}
+	k := &signingKey{
+		kid:       uuid.New().String(),
+		private:   priv,
+		createdAt: now,
+	}
+	m.keys = append(m.keys, k)
+	m.pruneLocked(now)
+	return k
+}
+
+// publicKey ищет открытый ключ по kid среди активного и ещё не удалённых ключей
+func (m *KeyManager) publicKey(kid string) (ed25519.PublicKey, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := time.Now()
+	for _, k := range m.keys {
+		if k.kid == kid && !m.expiredLocked(k, now) {
+			return k.private.Public().(ed25519.PublicKey), true
+		}
+	}
+	return nil, false
+}
+
+// JWKS возвращает открытые ключи для публикации
+func (m *KeyManager) JWKS() jwks.Set {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.pruneLocked(time.Now())
+	set := jwks.Set{Keys: make([]jwks.Key, 0, len(m.keys))}
+	for _, k := range m.keys {
+		set.Keys = append(set.Keys, jwks.FromEd25519(k.kid, k.private.Public().(ed25519.PublicKey)))
+	}
+	return set
+}
+
+func (m *KeyManager) expiredLocked(k *signingKey, now time.Time) bool {
+	return !k.retiredAt.IsZero() && now.Sub(k.retiredAt) > m.retainFor
+}
+
+func (m *KeyManager) pruneLocked(now time.Time) {
+	kept := m.keys[:0]
+	for _, k := range m.keys {
+		if !m.expiredLocked(k, now) {
+			kept = append(kept, k)
+		}
+	}
+	m.keys = kept
+}
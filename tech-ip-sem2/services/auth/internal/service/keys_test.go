@@ -0,0 +1,53 @@
+package service
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// Плановую ротацию выполняет ровно один из одновременных вызовов
+func TestKeyManagerConcurrentRotation(t *testing.T) {
+	const rotateEvery = 200 * time.Millisecond
+	m, err := NewKeyManager(rotateEvery, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := m.signingKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(rotateEvery + 10*time.Millisecond)
+
+	const signers = 200
+	kids := make(chan string, signers)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < signers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			k, err := m.signingKey()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			kids <- k.kid
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(kids)
+
+	seen := make(map[string]bool)
+	for kid := range kids {
+		seen[kid] = true
+	}
+	if len(seen) != 1 || seen[first.kid] {
+		t.Errorf("signers used keys %v, want one new key", seen)
+	}
+	if n := len(m.JWKS().Keys); n != 2 {
+		t.Errorf("JWKS has %d keys, want 2 (retired and active)", n)
+	}
+}
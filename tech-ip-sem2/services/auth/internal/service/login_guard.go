@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/models"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/repository"
+)
+
+// LockedError - вход временно заблокирован после серии неудачных попыток
+type LockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, retry after %s", e.RetryAfter)
+}
+
+// LoginGuardConfig - параметры защиты от перебора паролей
+type LoginGuardConfig struct {
+	MaxAttemptsPerUsername int           // неудач без задержки для одного username
+	MaxAttemptsPerIP       int           // неудач без задержки с одного IP
+	BaseDelay              time.Duration // задержка после первой неудачи сверх лимита, далее удваивается
+	MaxDelay               time.Duration // максимальная длительность блокировки
+	Window                 time.Duration // счётчик сбрасывается, если неудач не было дольше окна
+}
+
+// LoginGuard считает неудачные попытки входа по username и по IP
+// и блокирует вход с экспоненциально растущей задержкой
+type LoginGuard struct {
+	store repository.LoginAttemptStore
+	cfg   LoginGuardConfig
+}
+
+func NewLoginGuard(store repository.LoginAttemptStore, cfg LoginGuardConfig) *LoginGuard {
+	return &LoginGuard{store: store, cfg: cfg}
+}
+
+func usernameKey(username string) string { return "user:" + username }
+func ipKey(ip string) string             { return "ip:" + ip }
+
+// Check возвращает *LockedError, если по username или IP действует блокировка
+func (g *LoginGuard) Check(ctx context.Context, username, ip string) error {
+	now := time.Now()
+	wait, err := g.lockedFor(ctx, usernameKey(username), g.cfg.MaxAttemptsPerUsername, now)
+	if err != nil {
+		return err
+	}
+	if ip != "" {
+		ipWait, err := g.lockedFor(ctx, ipKey(ip), g.cfg.MaxAttemptsPerIP, now)
+		if err != nil {
+			return err
+		}
+		wait = max(wait, ipWait)
+	}
+	if wait > 0 {
+		return &LockedError{RetryAfter: wait}
+	}
+	return nil
+}
+
+// Failure учитывает неудачную попытку
+func (g *LoginGuard) Failure(ctx context.Context, username, ip string) error {
+	now := time.Now()
+	if _, err := g.store.RegisterFailure(ctx, usernameKey(username), now, g.cfg.Window); err != nil {
+		return err
+	}
+	if ip != "" {
+		if _, err := g.store.RegisterFailure(ctx, ipKey(ip), now, g.cfg.Window); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Success сбрасывает счётчик username. Счётчик IP не сбрасывается,
+// иначе вход в свой аккаунт обнулял бы перебор чужих с того же адреса.
+func (g *LoginGuard) Success(ctx context.Context, username string) error {
+	return g.store.Reset(ctx, usernameKey(username))
+}
+
+// PurgeStale удаляет счётчики, окно которых истекло
+func (g *LoginGuard) PurgeStale(ctx context.Context) (int64, error) {
+	return g.store.DeleteStale(ctx, time.Now().Add(-g.cfg.Window))
+}
+
+func (g *LoginGuard) lockedFor(ctx context.Context, key string, limit int, now time.Time) (time.Duration, error) {
+	a, err := g.store.Get(ctx, key)
+	if err != nil || a == nil {
+		return 0, err
+	}
+	if now.Sub(a.LastFailureAt) > g.cfg.Window {
+		return 0, nil
+	}
+	until := a.LastFailureAt.Add(g.delay(a, limit))
+	if !until.After(now) {
+		return 0, nil
+	}
+	return until.Sub(now), nil
+}
+
+// delay - BaseDelay * 2^(неудач сверх лимита - 1), не больше MaxDelay
+func (g *LoginGuard) delay(a *models.LoginAttempts, limit int) time.Duration {
+	over := a.Failures - limit
+	if over <= 0 {
+		return 0
+	}
+	d := g.cfg.BaseDelay
+	for i := 1; i < over && d < g.cfg.MaxDelay; i++ {
+		d *= 2
+	}
+	return min(d, g.cfg.MaxDelay)
+}
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/metrics"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/models"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/totp"
+)
+
+var (
+	ErrMFARequired        = errors.New("two-factor authentication required")
+	ErrInvalidOTP         = errors.New("invalid one-time code")
+	ErrInvalidChallenge   = errors.New("invalid or expired challenge")
+	ErrTOTPAlreadyEnabled = errors.New("two-factor authentication already enabled")
+	ErrTOTPNotEnrolled    = errors.New("two-factor enrollment not started")
+	ErrReauthRequired     = errors.New("current password or recent sign-in required")
+)
+
+// MFAConfig - параметры двухфакторной аутентификации
+type MFAConfig struct {
+	Issuer               string        // имя сервиса в приложении-аутентификаторе
+	ChallengeTTL         time.Duration // сколько ждать второй фактор после пароля
+	MaxChallengeAttempts int           // неверных кодов до аннулирования вызова
+}
+
+const (
+	recoveryCodeCount = 10
+	totpSkew          = 1 // допускаем соседние 30-секундные шаги
+
+	// reauthWindow - насколько недавним должен быть вход пользователя без пароля,
+	// чтобы подключить 2FA
+	reauthWindow = 5 * time.Minute
+)
+
+// recoveryAlphabet без похожих символов (0/o, 1/l)
+const recoveryAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// StartMFAChallenge создаёт вызов второго фактора после успешной проверки пароля
+func (s *AuthService) StartMFAChallenge(ctx context.Context, user *models.User) (string, time.Time, error) {
+	id, err := generateToken()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	challenge := &models.MFAChallenge{
+		ID:        id,
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(s.cfg.MFA.ChallengeTTL),
+	}
+	if err := s.mfaChallenges.Create(ctx, challenge); err != nil {
+		return "", time.Time{}, err
+	}
+	return id, challenge.ExpiresAt, nil
+}
+
+// CompleteMFAChallenge завершает вход TOTP-кодом или кодом восстановления.
+// Неверные коды учитываются в LoginGuard наравне с неверными паролями,
+// при блокировке возвращается *LockedError.
+func (s *AuthService) CompleteMFAChallenge(ctx context.Context, challengeID, code, recoveryCode string) (*models.User, error) {
+	challenge, err := s.mfaChallenges.Get(ctx, challengeID)
+	if err != nil {
+		return nil, err
+	}
+	if challenge == nil || !challenge.ExpiresAt.After(time.Now()) {
+		return nil, ErrInvalidChallenge
+	}
+
+	user, err := s.users.GetByID(ctx, challenge.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidChallenge
+	}
+
+	if err := s.guard.Check(ctx, user.Username, ClientInfoFromContext(ctx).IP); err != nil {
+		var locked *LockedError
+		if errors.As(err, &locked) {
+			metrics.LoginAttempt(metrics.LoginLocked)
+			s.record(ctx, AuditLoginFailed, user, "", map[string]string{"reason": "locked"})
+		}
+		return nil, err
+	}
+
+	if err := s.verifySecondFactor(ctx, user, code, recoveryCode); err != nil {
+		if errors.Is(err, ErrInvalidOTP) {
+			if ferr := s.secondFactorFailed(ctx, user); ferr != nil {
+				return nil, ferr
+			}
+			attempts, ierr := s.mfaChallenges.IncrementAttempts(ctx, challengeID)
+			if ierr != nil {
+				return nil, ierr
+			}
+			if attempts >= s.cfg.MFA.MaxChallengeAttempts {
+				if derr := s.mfaChallenges.Delete(ctx, challengeID); derr != nil {
+					return nil, derr
+				}
+			}
+		}
+		return nil, err
+	}
+
+	if err := s.mfaChallenges.Delete(ctx, challengeID); err != nil {
+		return nil, err
+	}
+	if err := s.guard.Success(ctx, user.Username); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// secondFactorFailed учитывает неверный TOTP-код или код восстановления:
+// метрика, аудит и неудача в LoginGuard по username и IP клиента
+func (s *AuthService) secondFactorFailed(ctx context.Context, user *models.User) error {
+	metrics.LoginAttempt(metrics.LoginInvalidOTP)
+	s.record(ctx, AuditLoginFailed, user, "", map[string]string{"reason": "invalid_otp"})
+	return s.guard.Failure(ctx, user.Username, ClientInfoFromContext(ctx).IP)
+}
+
+// EnrollTOTP начинает подключение 2FA: создаёт секрет, который включится после ConfirmTOTP.
+// Требует повторного подтверждения личности (см. reauthenticate).
+func (s *AuthService) EnrollTOTP(ctx context.Context, user *models.User, session *models.Session, password string) (string, string, error) {
+	if user.TOTPEnabled {
+		return "", "", ErrTOTPAlreadyEnabled
+	}
+	if err := s.reauthenticate(ctx, user, session, password); err != nil {
+		return "", "", err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return "", "", err
+	}
+	ok, err := s.users.SetTOTPSecret(ctx, user.ID, secret)
+	if err != nil {
+		return "", "", err
+	}
+	if !ok {
+		return "", "", ErrTOTPAlreadyEnabled
+	}
+	user.TOTPSecret = secret
+	return secret, totp.URI(s.cfg.MFA.Issuer, user.Username, secret, totp.DefaultParams), nil
+}
+
+// ConfirmTOTP включает 2FA после проверки первого кода и возвращает коды восстановления.
+// Коды показываются один раз, в хранилище остаются только хеши.
+// Требует повторного подтверждения личности (см. reauthenticate).
+func (s *AuthService) ConfirmTOTP(ctx context.Context, user *models.User, session *models.Session, password, code string) ([]string, error) {
+	if user.TOTPEnabled {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrTOTPNotEnrolled
+	}
+	if err := s.reauthenticate(ctx, user, session, password); err != nil {
+		return nil, err
+	}
+
+	step, err := s.checkTOTP(user, code)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	// Включается именно проверенный секрет: если его заменил параллельный EnrollTOTP, код не подходит
+	ok, err := s.users.EnableTOTP(ctx, user.ID, user.TOTPSecret, step, hashes)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+	user.TOTPEnabled = true
+	user.TOTPLastStep = step
+	user.RecoveryCodes = hashes
+	s.record(ctx, AuditTOTPEnabled, user, "", nil)
+	return codes, nil
+}
+
+// reauthenticate подтверждает, что 2FA подключает владелец учётной записи, а не тот,
+// кто завладел его сессией: нужен текущий пароль, а у пользователя без пароля (вход через
+// провайдера) - вход в этой сессии не раньше reauthWindow назад. Неверный пароль учитывается
+// в LoginGuard, при блокировке возвращается *LockedError.
+func (s *AuthService) reauthenticate(ctx context.Context, user *models.User, session *models.Session, password string) error {
+	if user.PasswordHash == "" {
+		if session == nil || time.Since(session.CreatedAt) > reauthWindow {
+			return ErrReauthRequired
+		}
+		return nil
+	}
+
+	ip := ClientInfoFromContext(ctx).IP
+	if err := s.guard.Check(ctx, user.Username, ip); err != nil {
+		return err
+	}
+	if !CheckPassword(user.PasswordHash, password) {
+		if err := s.guard.Failure(ctx, user.Username, ip); err != nil {
+			return err
+		}
+		return ErrReauthRequired
+	}
+	return nil
+}
+
+// verifySecondFactor проверяет TOTP-код или одноразовый код восстановления.
+// Код расходуется условным обновлением в хранилище, поэтому два одновременных
+// запроса с одним и тем же кодом не пройдут оба.
+func (s *AuthService) verifySecondFactor(ctx context.Context, user *models.User, code, recoveryCode string) error {
+	if recoveryCode != "" {
+		hash := hashToken(normalizeRecoveryCode(recoveryCode))
+		consumed, err := s.users.ConsumeRecoveryCode(ctx, user.ID, hash)
+		if err != nil {
+			return err
+		}
+		if !consumed {
+			return ErrInvalidOTP
+		}
+		return nil
+	}
+
+	step, err := s.checkTOTP(user, code)
+	if err != nil {
+		return err
+	}
+	advanced, err := s.users.AdvanceTOTPStep(ctx, user.ID, step)
+	if err != nil {
+		return err
+	}
+	if !advanced {
+		return ErrInvalidOTP
+	}
+	user.TOTPLastStep = step
+	return nil
+}
+
+// checkTOTP проверяет код и отклоняет уже использованные шаги (повтор перехваченного кода).
+// Окончательно шаг занимает AdvanceTOTPStep.
+func (s *AuthService) checkTOTP(user *models.User, code string) (int64, error) {
+	key, err := totp.DecodeSecret(user.TOTPSecret)
+	if err != nil {
+		return 0, err
+	}
+	step, ok := totp.Validate(key, strings.TrimSpace(code), time.Now(), totpSkew, totp.DefaultParams)
+	if !ok || step <= user.TOTPLastStep {
+		return 0, ErrInvalidOTP
+	}
+	return step, nil
+}
+
+// PurgeExpiredChallenges удаляет просроченные вызовы второго фактора
+func (s *AuthService) PurgeExpiredChallenges(ctx context.Context) (int64, error) {
+	return s.mfaChallenges.DeleteExpired(ctx, time.Now())
+}
+
+// generateRecoveryCodes возвращает коды вида xxxxx-xxxxx и их хеши
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	buf := make([]byte, 10)
+	for i := range codes {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		var sb strings.Builder
+		for j, b := range buf {
+			if j == 5 {
+				sb.WriteByte('-')
+			}
+			sb.WriteByte(recoveryAlphabet[int(b)%len(recoveryAlphabet)])
+		}
+		codes[i] = sb.String()
+		hashes[i] = hashToken(normalizeRecoveryCode(codes[i]))
+	}
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/models"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/repository"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/totp"
+)
+
+const testPassword = "Correct-Horse-42"
+
+func newMFATestUser(t *testing.T, s *AuthService, password string) *models.User {
+	t.Helper()
+	user := &models.User{Username: "alice", Email: "alice@example.com", Roles: []string{RoleUser}}
+	if password != "" {
+		hash, err := HashPassword(password)
+		if err != nil {
+			t.Fatal(err)
+		}
+		user.PasswordHash = hash
+	}
+	if err := s.users.Create(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func TestEnrollTOTPRequiresReauth(t *testing.T) {
+	fresh := &models.Session{CreatedAt: time.Now()}
+	stale := &models.Session{CreatedAt: time.Now().Add(-reauthWindow - time.Minute)}
+
+	cases := []struct {
+		name         string
+		userPassword string // пусто - пользователь без пароля (вход через провайдера)
+		session      *models.Session
+		password     string
+		wantErr      error
+	}{
+		{"correct password", testPassword, stale, testPassword, nil},
+		{"missing password", testPassword, fresh, "", ErrReauthRequired},
+		{"wrong password", testPassword, fresh, "wrong-password-1", ErrReauthRequired},
+		{"passwordless with recent sign-in", "", fresh, "", nil},
+		{"passwordless with old sign-in", "", stale, "", ErrReauthRequired},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, _ := newTestService(t, Stores{}, nil, Config{})
+			user := newMFATestUser(t, s, tc.userPassword)
+
+			_, _, err := s.EnrollTOTP(context.Background(), user, tc.session, tc.password)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("EnrollTOTP error = %v, want %v", err, tc.wantErr)
+			}
+			stored, _ := s.users.GetByID(context.Background(), user.ID)
+			if (stored.TOTPSecret != "") != (tc.wantErr == nil) {
+				t.Fatalf("secret stored = %v, want %v", stored.TOTPSecret != "", tc.wantErr == nil)
+			}
+		})
+	}
+}
+
+// Подключение 2FA меняет только её поля: устаревшая копия пользователя не затирает остальные
+func TestConfirmTOTPUpdatesOnlyTOTPColumns(t *testing.T) {
+	s, audit := newTestService(t, Stores{}, nil, Config{})
+	ctx := context.Background()
+	user := newMFATestUser(t, s, testPassword)
+	session := &models.Session{CreatedAt: time.Now()}
+
+	secret, _, err := s.EnrollTOTP(ctx, user, session, testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Параллельное изменение, которого нет в копии user
+	current, _ := s.users.GetByID(ctx, user.ID)
+	current.Email = "alice@new.example.com"
+	if err := s.users.Update(ctx, current); err != nil {
+		t.Fatal(err)
+	}
+
+	key, _ := totp.DecodeSecret(secret)
+	code := totp.Code(key, time.Now(), totp.DefaultParams)
+	if _, err := s.ConfirmTOTP(ctx, user, session, "wrong-password-1", code); !errors.Is(err, ErrReauthRequired) {
+		t.Fatalf("ConfirmTOTP with wrong password error = %v, want %v", err, ErrReauthRequired)
+	}
+	codes, err := s.ConfirmTOTP(ctx, user, session, testPassword, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("recovery codes = %d, want %d", len(codes), recoveryCodeCount)
+	}
+
+	stored, _ := s.users.GetByID(ctx, user.ID)
+	if !stored.TOTPEnabled || stored.TOTPSecret != secret || len(stored.RecoveryCodes) != recoveryCodeCount {
+		t.Fatalf("stored TOTP state = enabled %v, secret match %v, recovery codes %d",
+			stored.TOTPEnabled, stored.TOTPSecret == secret, len(stored.RecoveryCodes))
+	}
+	if stored.Email != "alice@new.example.com" {
+		t.Fatalf("email = %q: TOTP update overwrote other columns", stored.Email)
+	}
+	events, err := audit.List(ctx, repository.AuditFilter{Type: AuditTOTPEnabled})
+	if err != nil || len(events) != 1 {
+		t.Fatalf("%s events = %d (err %v), want 1", AuditTOTPEnabled, len(events), err)
+	}
+
+	// Включённый секрет не перезаписывается, даже с верным паролем
+	if _, _, err := s.EnrollTOTP(ctx, current, session, testPassword); !errors.Is(err, ErrTOTPAlreadyEnabled) {
+		t.Fatalf("EnrollTOTP after enable error = %v, want %v", err, ErrTOTPAlreadyEnabled)
+	}
+	if stored, _ := s.users.GetByID(ctx, user.ID); stored.TOTPSecret != secret {
+		t.Fatal("enabled TOTP secret was overwritten")
+	}
+}
+
+// Код от секрета, заменённого повторным EnrollTOTP, не включает 2FA
+func TestConfirmTOTPRejectsReplacedSecret(t *testing.T) {
+	s, _ := newTestService(t, Stores{}, nil, Config{})
+	ctx := context.Background()
+	user := newMFATestUser(t, s, testPassword)
+	session := &models.Session{CreatedAt: time.Now()}
+
+	if _, _, err := s.EnrollTOTP(ctx, user, session, testPassword); err != nil {
+		t.Fatal(err)
+	}
+	first := *user
+	if _, _, err := s.EnrollTOTP(ctx, user, session, testPassword); err != nil {
+		t.Fatal(err)
+	}
+
+	key, _ := totp.DecodeSecret(first.TOTPSecret)
+	code := totp.Code(key, time.Now(), totp.DefaultParams)
+	if _, err := s.ConfirmTOTP(ctx, &first, session, testPassword, code); err == nil {
+		t.Fatal("ConfirmTOTP enabled a replaced secret")
+	}
+	if stored, _ := s.users.GetByID(ctx, user.ID); stored.TOTPEnabled {
+		t.Fatal("TOTP enabled with a replaced secret")
+	}
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/models"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/oidc"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/repository"
+)
+
+var (
+	ErrOIDCDisabled     = errors.New("oidc login is not configured")
+	ErrInvalidOIDCState = errors.New("invalid or expired oidc state")
+)
+
+// oidcLoginTTL - сколько ждём возврата пользователя от провайдера
+const oidcLoginTTL = 10 * time.Minute
+
+var usernameInvalidChars = regexp.MustCompile(`[^a-z0-9_.-]+`)
+
+// UseOIDC включает вход через внешнего OIDC-провайдера
+func (s *AuthService) UseOIDC(p *oidc.Provider) {
+	s.oidc = p
+}
+
+// OIDCEnabled сообщает, настроен ли вход через внешнего провайдера
+func (s *AuthService) OIDCEnabled() bool {
+	return s.oidc != nil
+}
+
+// StartOIDCLogin начинает вход через провайдера: сохраняет state, nonce и PKCE verifier
+// и возвращает адрес, на который нужно перенаправить браузер
+func (s *AuthService) StartOIDCLogin(ctx context.Context) (string, string, error) {
+	if s.oidc == nil {
+		return "", "", ErrOIDCDisabled
+	}
+
+	state, err := generateToken()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := generateToken()
+	if err != nil {
+		return "", "", err
+	}
+	verifier, challenge, err := oidc.NewPKCE()
+	if err != nil {
+		return "", "", err
+	}
+
+	authURL, err := s.oidc.AuthCodeURL(ctx, state, nonce, challenge)
+	if err != nil {
+		return "", "", err
+	}
+
+	login := &models.OIDCLogin{
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(oidcLoginTTL),
+	}
+	if err := s.oidcLogins.Create(ctx, login); err != nil {
+		return "", "", err
+	}
+	return authURL, state, nil
+}
+
+// CompleteOIDCLogin завершает вход по коду из callback: обменивает код, проверяет ID-токен
+// и находит пользователя по привязке, при первом входе создаёт его (JIT provisioning)
+func (s *AuthService) CompleteOIDCLogin(ctx context.Context, state, code string) (*models.User, error) {
+	if s.oidc == nil {
+		return nil, ErrOIDCDisabled
+	}
+
+	login, err := s.oidcLogins.Take(ctx, state)
+	if err != nil {
+		return nil, err
+	}
+	if login == nil || !login.ExpiresAt.After(time.Now()) {
+		return nil, ErrInvalidOIDCState
+	}
+
+	tokens, err := s.oidc.Exchange(ctx, code, login.CodeVerifier)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := s.oidc.VerifyIDToken(ctx, tokens.IDToken, login.Nonce)
+	if err != nil {
+		s.record(ctx, AuditLoginFailed, nil, "", map[string]string{
+			"reason": "invalid_id_token",
+			"issuer": s.oidc.Issuer(),
+		})
+		return nil, err
+	}
+
+	return s.userForIdentity(ctx, s.oidc.Issuer(), claims)
+}
+
+// userForIdentity возвращает пользователя, привязанного к учётной записи провайдера, или создаёт нового.
+// Существующие локальные учётные записи по email автоматически не привязываются:
+// иначе владелец IdP мог бы войти в чужой аккаунт.
+func (s *AuthService) userForIdentity(ctx context.Context, issuer string, claims *oidc.Claims) (*models.User, error) {
+	identity, err := s.identities.Get(ctx, issuer, claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if identity != nil {
+		user, err := s.users.GetByID(ctx, identity.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, ErrUserNotFound
+		}
+		return user, nil
+	}
+
+	user, err := s.provisionUser(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.identities.Create(ctx, &models.UserIdentity{
+		Issuer:    issuer,
+		Subject:   claims.Subject,
+		UserID:    user.ID,
+		Email:     normalizeEmail(claims.Email),
+		CreatedAt: time.Now(),
+	})
+	if errors.Is(err, repository.ErrAlreadyExists) {
+		// Параллельный первый вход уже создал привязку
+		return s.userForIdentity(ctx, issuer, claims)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.record(ctx, AuditUserRegistered, user, "", map[string]string{
+		"method":  "oidc",
+		"issuer":  issuer,
+		"subject": claims.Subject,
+	})
+	return user, nil
+}
+
+// provisionUser создаёт локального пользователя без пароля для входа через провайдера
+func (s *AuthService) provisionUser(ctx context.Context, claims *oidc.Claims) (*models.User, error) {
+	// Email сохраняем, только если провайдер его подтвердил и он не занят
+	var email string
+	if claims.EmailVerified && claims.Email != "" {
+		existing, err := s.users.GetByEmail(ctx, normalizeEmail(claims.Email))
+		if err != nil {
+			return nil, err
+		}
+		if existing == nil {
+			email = normalizeEmail(claims.Email)
+		}
+	}
+
+	base := oidcUsername(claims)
+	username := base
+	for attempt := 0; ; attempt++ {
+		now := time.Now()
+		user := &models.User{
+			ID:        newUserID(),
+			Username:  username,
+			Email:     email,
+			Roles:     []string{RoleUser},
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		err := s.users.Create(ctx, user)
+		if err == nil {
+			return user, nil
+		}
+		if !errors.Is(err, repository.ErrAlreadyExists) || attempt >= 5 {
+			return nil, err
+		}
+		// Имя (или email) занято - пробуем с суффиксом, email не сохраняем
+		suffix := make([]byte, 2)
+		if _, err := rand.Read(suffix); err != nil {
+			return nil, err
+		}
+		username = truncateUsername(base, 27) + "-" + hex.EncodeToString(suffix)
+		email = ""
+	}
+}
+
+// oidcUsername выводит допустимое имя пользователя из preferred_username или email
+func oidcUsername(claims *oidc.Claims) string {
+	candidate := claims.PreferredUsername
+	if candidate == "" {
+		candidate, _, _ = strings.Cut(claims.Email, "@")
+	}
+	candidate = usernameInvalidChars.ReplaceAllString(normalizeUsername(candidate), "-")
+	candidate = strings.TrimLeft(candidate, "_.-")
+	candidate = truncateUsername(candidate, 32)
+	if len(candidate) < 3 {
+		// Слишком короткое имя - при создании к нему добавится случайный суффикс
+		return "user"
+	}
+	return candidate
+}
+
+func truncateUsername(username string, max int) string {
+	if len(username) > max {
+		return username[:max]
+	}
+	return username
+}
+
+// PurgeExpiredOIDCLogins удаляет незавершённые входы через провайдера
+func (s *AuthService) PurgeExpiredOIDCLogins(ctx context.Context) (int64, error) {
+	return s.oidcLogins.DeleteExpired(ctx, time.Now())
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/oidc"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/oidc/oidctest"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/repository"
+)
+
+// newOIDCTestService - сервис на хранилищах в памяти со входом через тестовый IdP
+func newOIDCTestService(t *testing.T) (*AuthService, *oidctest.IdP, repository.IdentityRepository) {
+	t.Helper()
+	keys, err := NewKeyManager(time.Hour, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	identities := repository.NewMemoryIdentityRepository()
+	s := NewAuthService(Stores{
+		Users:      repository.NewMemoryUserRepository(),
+		Sessions:   repository.NewMemorySessionRepository(),
+		OIDCLogins: repository.NewMemoryOIDCLoginStore(),
+		Identities: identities,
+	}, keys, NewLoginGuard(repository.NewMemoryLoginAttemptStore(), LoginGuardConfig{}), nil,
+		NewAuditor(repository.NewMemoryAuditRepository(), logger), Config{})
+
+	idp := oidctest.New("tasks-app")
+	t.Cleanup(idp.Close)
+	s.UseOIDC(oidc.NewProvider(oidc.Config{
+		IssuerURL:   idp.Issuer(),
+		ClientID:    "tasks-app",
+		RedirectURL: "http://localhost:8081/v1/auth/oidc/callback",
+	}, idp.Server.Client()))
+	return s, idp, identities
+}
+
+// oidcLogin проходит вход через провайдера так, как это делает браузер
+func oidcLogin(t *testing.T, s *AuthService, idp *oidctest.IdP, id oidctest.Identity) (string, string) {
+	t.Helper()
+	authURL, state, err := s.StartOIDCLogin(context.Background())
+	if err != nil {
+		t.Fatalf("StartOIDCLogin: %v", err)
+	}
+	code, returnedState, err := idp.Authorize(authURL, id)
+	if err != nil {
+		t.Fatalf("Authorize: %v", err)
+	}
+	if returnedState != state {
+		t.Fatalf("state = %q, want %q", returnedState, state)
+	}
+	return state, code
+}
+
+func TestOIDCLoginProvisionsUser(t *testing.T) {
+	s, idp, identities := newOIDCTestService(t)
+	ctx := context.Background()
+	id := oidctest.Identity{Subject: "idp-42", Email: "Alice@Example.com", EmailVerified: true, PreferredUsername: "Alice"}
+
+	state, code := oidcLogin(t, s, idp, id)
+	user, err := s.CompleteOIDCLogin(ctx, state, code)
+	if err != nil {
+		t.Fatalf("CompleteOIDCLogin: %v", err)
+	}
+	if user.Username != "alice" || user.Email != "alice@example.com" || user.PasswordHash != "" {
+		t.Errorf("provisioned user = %+v, want alice without password", user)
+	}
+	if len(user.Roles) != 1 || user.Roles[0] != RoleUser {
+		t.Errorf("roles = %v, want [%s]", user.Roles, RoleUser)
+	}
+	identity, err := identities.Get(ctx, idp.Issuer(), id.Subject)
+	if err != nil || identity == nil || identity.UserID != user.ID {
+		t.Fatalf("identity = %+v, %v; want link to %s", identity, err, user.ID)
+	}
+
+	// Повторный вход находит того же пользователя по привязке, а не создаёт нового
+	state, code = oidcLogin(t, s, idp, id)
+	again, err := s.CompleteOIDCLogin(ctx, state, code)
+	if err != nil {
+		t.Fatalf("second CompleteOIDCLogin: %v", err)
+	}
+	if again.ID != user.ID {
+		t.Errorf("second login user = %s, want %s", again.ID, user.ID)
+	}
+}
+
+func TestOIDCLoginDoesNotLinkLocalAccountByEmail(t *testing.T) {
+	s, idp, _ := newOIDCTestService(t)
+	ctx := context.Background()
+	local, err := s.CreateUser(ctx, "alice", "alice@example.com", "Correct-Horse-42", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, code := oidcLogin(t, s, idp, oidctest.Identity{
+		Subject: "idp-7", Email: "alice@example.com", EmailVerified: true, PreferredUsername: "alice",
+	})
+	user, err := s.CompleteOIDCLogin(ctx, state, code)
+	if err != nil {
+		t.Fatalf("CompleteOIDCLogin: %v", err)
+	}
+	if user.ID == local.ID {
+		t.Fatal("identity provider login was linked to an existing local account")
+	}
+	if user.Email != "" || user.Username == "alice" {
+		t.Errorf("provisioned user = %+v, want new name and no email", user)
+	}
+}
+
+func TestOIDCLoginUnverifiedEmailNotStored(t *testing.T) {
+	s, idp, _ := newOIDCTestService(t)
+
+	state, code := oidcLogin(t, s, idp, oidctest.Identity{Subject: "idp-9", Email: "bob@example.com"})
+	user, err := s.CompleteOIDCLogin(context.Background(), state, code)
+	if err != nil {
+		t.Fatalf("CompleteOIDCLogin: %v", err)
+	}
+	if user.Username != "bob" || user.Email != "" {
+		t.Errorf("provisioned user = %+v, want bob without email", user)
+	}
+}
+
+func TestOIDCLoginStateIsSingleUse(t *testing.T) {
+	s, idp, _ := newOIDCTestService(t)
+	ctx := context.Background()
+
+	state, code := oidcLogin(t, s, idp, oidctest.Identity{Subject: "idp-1", PreferredUsername: "carol"})
+	if _, err := s.CompleteOIDCLogin(ctx, state, code); err != nil {
+		t.Fatalf("CompleteOIDCLogin: %v", err)
+	}
+	if _, err := s.CompleteOIDCLogin(ctx, state, code); !errors.Is(err, ErrInvalidOIDCState) {
+		t.Fatalf("reused state error = %v, want %v", err, ErrInvalidOIDCState)
+	}
+}
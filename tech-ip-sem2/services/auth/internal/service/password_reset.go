@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/mailer"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/models"
+)
+
+var ErrInvalidResetToken = errors.New("invalid or expired reset token")
+
+// PasswordResetConfig - параметры сброса пароля
+type PasswordResetConfig struct {
+	TTL time.Duration
+	URL string // адрес страницы сброса; токен добавляется в конец
+}
+
+// RequestPasswordReset отправляет письмо со ссылкой для сброса пароля.
+// Для неизвестного email ничего не делает и не возвращает ошибку, чтобы по ответу
+// нельзя было узнать, зарегистрирован ли адрес.
+func (s *AuthService) RequestPasswordReset(ctx context.Context, email string) error {
+	email = normalizeEmail(email)
+	if email == "" {
+		return nil
+	}
+
+	user, err := s.users.GetByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return nil
+	}
+
+	// Действует только последняя ссылка
+	if err := s.passwordResets.DeleteByUserID(ctx, user.ID); err != nil {
+		return err
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	reset := &models.PasswordReset{
+		ID:        "pr_" + uuid.New().String(),
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.cfg.PasswordReset.TTL),
+	}
+	if err := s.passwordResets.Create(ctx, reset); err != nil {
+		return err
+	}
+	s.record(ctx, AuditPasswordResetRequest, user, "", nil)
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Password reset",
+		Body: fmt.Sprintf("Hello, %s!\n\n"+
+			"To set a new password, open the link below (valid for %s):\n\n%s%s\n\n"+
+			"If you did not request a password reset, ignore this email.\n",
+			user.Username, s.cfg.PasswordReset.TTL, s.cfg.PasswordReset.URL, token),
+	})
+}
+
+// ConfirmPasswordReset устанавливает новый пароль по одноразовому токену.
+// Все сессии, refresh-токены и персональные токены пользователя отзываются.
+func (s *AuthService) ConfirmPasswordReset(ctx context.Context, token, newPassword string) error {
+	if token == "" {
+		return ErrInvalidResetToken
+	}
+	reset, err := s.passwordResets.GetByTokenHash(ctx, hashToken(token))
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if reset == nil || reset.UsedAt != nil || !reset.ExpiresAt.After(now) {
+		return ErrInvalidResetToken
+	}
+
+	user, err := s.users.GetByID(ctx, reset.UserID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrInvalidResetToken
+	}
+
+	// Проверяем пароль до того, как потратить токен
+	verr := &ValidationError{}
+	validatePassword(verr, user.Username, newPassword)
+	if len(verr.Fields) > 0 {
+		return verr
+	}
+
+	ok, err := s.passwordResets.MarkUsed(ctx, reset.ID, now)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidResetToken
+	}
+
+	hash, err := HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+	user.PasswordHash = hash
+	user.UpdatedAt = now
+	if err := s.users.Update(ctx, user); err != nil {
+		return err
+	}
+
+	if _, err := s.sessions.DeleteByUserID(ctx, user.ID); err != nil {
+		return err
+	}
+	// Персональный токен мог создать тот, кто завладел учётной записью до сброса
+	patsRevoked, err := s.accessTokens.DeleteByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	s.revokeSubject(user)
+	if err := s.refreshTokens.RevokeByUserID(ctx, user.ID, now); err != nil {
+		return err
+	}
+	s.record(ctx, AuditPasswordChanged, user, "", map[string]string{"method": "reset"})
+	if patsRevoked > 0 {
+		s.record(ctx, AuditAccessTokenRevoked, user, "", map[string]string{
+			"reason":  "password_reset",
+			"revoked": strconv.FormatInt(patsRevoked, 10),
+		})
+	}
+	// Владелец подтвердил доступ к почте - снимаем блокировку входа
+	return s.guard.Success(ctx, user.Username)
+}
+
+// PurgeExpiredPasswordResets удаляет просроченные токены сброса пароля
+func (s *AuthService) PurgeExpiredPasswordResets(ctx context.Context) (int64, error) {
+	return s.passwordResets.DeleteExpired(ctx, time.Now())
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/mailer"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/repository"
+)
+
+const testResetURL = "http://localhost:8081/reset-password?token="
+
+// outbox - почтовый ящик в памяти
+type outbox struct {
+	mu       sync.Mutex
+	messages []mailer.Message
+}
+
+func (o *outbox) Send(ctx context.Context, msg mailer.Message) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.messages = append(o.messages, msg)
+	return nil
+}
+
+// resetToken достаёт токен из ссылки в последнем письме
+func (o *outbox) resetToken(t *testing.T) string {
+	t.Helper()
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if len(o.messages) == 0 {
+		t.Fatal("no reset email sent")
+	}
+	body := o.messages[len(o.messages)-1].Body
+	i := strings.Index(body, testResetURL)
+	if i < 0 {
+		t.Fatalf("reset link not found in %q", body)
+	}
+	return strings.Fields(body[i+len(testResetURL):])[0]
+}
+
+func newResetTestService(t *testing.T) (*AuthService, *outbox, *repository.MemoryAuditRepository) {
+	t.Helper()
+	box := &outbox{}
+	s, audit := newTestService(t, Stores{
+		RefreshTokens:  repository.NewMemoryRefreshTokenRepository(),
+		PasswordResets: repository.NewMemoryPasswordResetRepository(),
+		AccessTokens:   repository.NewMemoryAccessTokenRepository(),
+	}, box, Config{PasswordReset: PasswordResetConfig{TTL: time.Hour, URL: testResetURL}})
+	return s, box, audit
+}
+
+func TestConfirmPasswordResetRevokesCredentials(t *testing.T) {
+	s, box, audit := newResetTestService(t)
+	ctx := context.Background()
+
+	user, err := s.CreateUser(ctx, "alice", "alice@example.com", "Correct-Horse-42", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionToken, _, err := s.CreateSession(ctx, user, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pat, _, err := s.CreateAccessToken(ctx, user, "ci", []string{ScopeTasksRead}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := s.VerifyToken(ctx, pat); err != nil || info == nil {
+		t.Fatalf("personal token before reset = %+v, %v; want valid", info, err)
+	}
+
+	if err := s.RequestPasswordReset(ctx, "alice@example.com"); err != nil {
+		t.Fatalf("RequestPasswordReset: %v", err)
+	}
+	if err := s.ConfirmPasswordReset(ctx, box.resetToken(t), "New-Battery-77"); err != nil {
+		t.Fatalf("ConfirmPasswordReset: %v", err)
+	}
+
+	if info, err := s.VerifyToken(ctx, pat); err != nil || info != nil {
+		t.Errorf("personal token after reset = %+v, %v; want rejected", info, err)
+	}
+	if tokens, _ := s.ListAccessTokens(ctx, user); len(tokens) != 0 {
+		t.Errorf("personal tokens after reset = %d, want 0", len(tokens))
+	}
+	if session, _, err := s.ValidateSession(ctx, sessionToken); err == nil && session != nil {
+		t.Error("session survived password reset")
+	}
+	if _, err := s.checkCredentials(ctx, "alice", "Correct-Horse-42"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("old password error = %v, want %v", err, ErrInvalidCredentials)
+	}
+
+	events, err := audit.List(ctx, repository.AuditFilter{Type: AuditAccessTokenRevoked})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Details["reason"] != "password_reset" || events[0].Details["revoked"] != "1" {
+		t.Errorf("access token audit events = %+v, want one password_reset revocation", events)
+	}
+}
+
+func TestConfirmPasswordResetRejects(t *testing.T) {
+	cases := []struct {
+		name     string
+		token    func(token string) string
+		password string
+		wantErr  func(error) bool
+	}{
+		{
+			name:     "unknown token",
+			token:    func(string) string { return "not-a-token" },
+			password: "New-Battery-77",
+			wantErr:  func(err error) bool { return errors.Is(err, ErrInvalidResetToken) },
+		},
+		{
+			name:     "weak password",
+			token:    func(token string) string { return token },
+			password: "short",
+			wantErr:  func(err error) bool { var verr *ValidationError; return errors.As(err, &verr) },
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, box, _ := newResetTestService(t)
+			ctx := context.Background()
+			user, err := s.CreateUser(ctx, "alice", "alice@example.com", "Correct-Horse-42", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pat, _, err := s.CreateAccessToken(ctx, user, "ci", []string{ScopeTasksRead}, time.Hour)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := s.RequestPasswordReset(ctx, "alice@example.com"); err != nil {
+				t.Fatal(err)
+			}
+
+			err = s.ConfirmPasswordReset(ctx, tc.token(box.resetToken(t)), tc.password)
+			if !tc.wantErr(err) {
+				t.Fatalf("ConfirmPasswordReset error = %v", err)
+			}
+			// Неудачный сброс ничего не отзывает
+			if info, _ := s.VerifyToken(ctx, pat); info == nil {
+				t.Error("personal token revoked by failed reset")
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/models"
+)
+
+// Роли пользователей (хранятся в users.roles)
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// Разрешения, которые роли дают пользователю. Передаются другим сервисам
+// в VerifyResponse/VerifySessionResponse и в JWT (claim permissions).
+const (
+	PermTasksRead       = "tasks:read"
+	PermTasksWrite      = "tasks:write"
+	PermTasksReadAll    = "tasks:read_all"    // задачи всех пользователей, включая удалённые
+	PermTasksHardDelete = "tasks:hard_delete" // безвозвратное удаление
+	PermUsersManage     = "users:manage"      // назначение ролей
+	PermAuditRead       = "audit:read"        // журнал безопасности
+)
+
+var rolePermissions = map[string][]string{
+	RoleUser:  {PermTasksRead, PermTasksWrite},
+	RoleAdmin: {PermTasksRead, PermTasksWrite, PermTasksReadAll, PermTasksHardDelete, PermUsersManage, PermAuditRead},
+}
+
+var (
+	ErrForbidden    = errors.New("forbidden")
+	ErrSelfDemotion = errors.New("cannot remove own admin role")
+)
+
+// Permissions возвращает отсортированный набор разрешений для ролей. Неизвестные роли игнорируются.
+func Permissions(roles []string) []string {
+	seen := make(map[string]bool)
+	var perms []string
+	for _, role := range roles {
+		for _, p := range rolePermissions[role] {
+			if !seen[p] {
+				seen[p] = true
+				perms = append(perms, p)
+			}
+		}
+	}
+	sort.Strings(perms)
+	return perms
+}
+
+// HasPermission проверяет, дают ли роли пользователя разрешение
+func HasPermission(user *models.User, permission string) bool {
+	for _, role := range user.Roles {
+		for _, p := range rolePermissions[role] {
+			if p == permission {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SetUserRoles заменяет роли пользователя. Доступно только с разрешением users:manage.
+// Ошибки валидации возвращаются как *ValidationError.
+func (s *AuthService) SetUserRoles(ctx context.Context, actor *models.User, username string, roles []string) (*models.User, error) {
+	if !HasPermission(actor, PermUsersManage) {
+		return nil, ErrForbidden
+	}
+
+	roles = normalizeList(roles)
+	verr := &ValidationError{}
+	if len(roles) == 0 {
+		verr.add("roles", "at least one role is required")
+	}
+	for _, role := range roles {
+		if _, ok := rolePermissions[role]; !ok {
+			verr.add("roles", "unknown role "+role)
+		}
+	}
+	if len(verr.Fields) > 0 {
+		return nil, verr
+	}
+
+	user, err := s.users.GetByUsername(ctx, normalizeUsername(username))
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	// Защита от ситуации, когда в системе не остаётся ни одного администратора
+	if user.ID == actor.ID && !containsString(roles, RoleAdmin) {
+		return nil, ErrSelfDemotion
+	}
+
+	previous := strings.Join(user.Roles, " ")
+	user.Roles = roles
+	user.UpdatedAt = time.Now()
+	if err := s.users.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	s.revokeSubject(user)
+	s.record(ctx, AuditRolesChanged, actor, "", map[string]string{
+		"user":     user.Username,
+		"previous": previous,
+		"roles":    strings.Join(roles, " "),
+	})
+	return user, nil
+}
+
+func containsString(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"sync"
+
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/models"
+)
+
+// revocationBuffer - сколько событий может накопиться у медленного подписчика
+const revocationBuffer = 64
+
+// Revocation - отзыв учётных данных, о котором уведомляются клиенты с кешем проверок
+type Revocation struct {
+	TokenHashes []string // хеши отозванных session_id и персональных токенов
+	Subject     string   // все проверки пользователя недействительны (имя пользователя)
+}
+
+// revocationFeed рассылает отзывы подписчикам (gRPC WatchRevocations).
+// Подписчик, не успевающий читать события, отключается: переподключившись,
+// он сбросит кеш целиком и не пропустит отзыв.
+type revocationFeed struct {
+	mu          sync.Mutex
+	subscribers map[chan Revocation]struct{}
+	closed      bool
+}
+
+func newRevocationFeed() *revocationFeed {
+	return &revocationFeed{subscribers: make(map[chan Revocation]struct{})}
+}
+
+func (f *revocationFeed) subscribe() (<-chan Revocation, func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ch := make(chan Revocation, revocationBuffer)
+	if f.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	f.subscribers[ch] = struct{}{}
+	return ch, func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.removeLocked(ch)
+	}
+}
+
+func (f *revocationFeed) publish(r Revocation) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for ch := range f.subscribers {
+		select {
+		case ch <- r:
+		default:
+			f.removeLocked(ch)
+		}
+	}
+}
+
+func (f *revocationFeed) close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.closed = true
+	for ch := range f.subscribers {
+		f.removeLocked(ch)
+	}
+}
+
+func (f *revocationFeed) removeLocked(ch chan Revocation) {
+	if _, ok := f.subscribers[ch]; ok {
+		delete(f.subscribers, ch)
+		close(ch)
+	}
+}
+
+// SubscribeRevocations подписывает на отзывы учётных данных. Канал закрывается,
+// если подписчик отстал или сервис останавливается; cancel нужно вызвать по завершении.
+func (s *AuthService) SubscribeRevocations() (<-chan Revocation, func()) {
+	return s.revocations.subscribe()
+}
+
+// CloseRevocations завершает все подписки на отзывы (при остановке сервиса)
+func (s *AuthService) CloseRevocations() {
+	s.revocations.close()
+}
+
+// revokeTokens уведомляет об отзыве конкретных session_id или персональных токенов
+func (s *AuthService) revokeTokens(tokenHashes ...string) {
+	s.revocations.publish(Revocation{TokenHashes: tokenHashes})
+}
+
+// revokeSubject уведомляет, что закешированные проверки пользователя больше не действительны
+func (s *AuthService) revokeSubject(user *models.User) {
+	s.revocations.publish(Revocation{Subject: user.Username})
+}
@@ -0,0 +1,279 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/metrics"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/models"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/shared/csrf"
+)
+
+// SessionConfig - параметры жизни сессий
+type SessionConfig struct {
+	TTL         time.Duration // время жизни без активности, продлевается при каждом обращении
+	MaxLifetime time.Duration // абсолютный предел жизни сессии, продление его не превышает
+	CSRFSecret  []byte        // секрет для HMAC CSRF-токенов, привязанных к сессии
+}
+
+// sessionTouchInterval ограничивает частоту записи last_seen_at при продлении сессии
+const sessionTouchInterval = time.Minute
+
+// maxUserAgentLength - сколько символов User-Agent сохраняем вместе с сессией
+const maxUserAgentLength = 512
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// generateToken возвращает 256 бит криптографически случайных данных в base64url
+func generateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken - SHA-256 от токена; в хранилище попадает только хеш
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// SessionMaxAge - время жизни session cookie
+func (s *AuthService) SessionMaxAge() time.Duration {
+	return s.cfg.Session.MaxLifetime
+}
+
+// CSRFToken возвращает CSRF-токен для значения session cookie
+func (s *AuthService) CSRFToken(sessionToken string) string {
+	return csrf.Token(s.cfg.Session.CSRFSecret, sessionToken)
+}
+
+// ValidCSRF проверяет CSRF-токен для значения session cookie
+func (s *AuthService) ValidCSRF(sessionToken, token string) bool {
+	return csrf.Valid(s.cfg.Session.CSRFSecret, sessionToken, token)
+}
+
+// CreateSession открывает новую сессию со случайным идентификатором и возвращает значение для cookie.
+// Сессия, пришедшая с запросом (previousToken), удаляется - так новый вход всегда получает
+// свежий идентификатор (защита от session fixation).
+func (s *AuthService) CreateSession(ctx context.Context, user *models.User, previousToken string) (string, *models.Session, error) {
+	if previousToken != "" {
+		old, err := s.sessions.GetByTokenHash(ctx, hashToken(previousToken))
+		if err != nil {
+			return "", nil, err
+		}
+		if old != nil {
+			if err := s.sessions.Delete(ctx, old.ID); err != nil {
+				return "", nil, err
+			}
+			s.revokeTokens(old.TokenHash)
+		}
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+	client := ClientInfoFromContext(ctx)
+	session := &models.Session{
+		ID:         "s_" + uuid.New().String(),
+		TokenHash:  hashToken(token),
+		UserID:     user.ID,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  s.sessionExpiry(now, now),
+		IP:         client.IP,
+		UserAgent:  truncateUserAgent(client.UserAgent),
+	}
+	if err := s.sessions.Create(ctx, session); err != nil {
+		return "", nil, err
+	}
+	return token, session, nil
+}
+
+// RecordLogin учитывает в метриках и журнале успешный вход, завершившийся открытием сессии.
+// Регистрация тоже открывает сессию, но входом не считается и сюда не попадает.
+func (s *AuthService) RecordLogin(ctx context.Context, user *models.User, session *models.Session, method string) {
+	metrics.LoginAttempt(metrics.LoginSuccess)
+	s.record(ctx, AuditLoginSucceeded, user, "", map[string]string{
+		"session_id": session.ID,
+		"method":     method,
+	})
+}
+
+// ValidateSession проверяет cookie сессии и продлевает её (sliding expiration).
+// Для неизвестной или истёкшей сессии возвращает (nil, nil, nil).
+func (s *AuthService) ValidateSession(ctx context.Context, token string) (*models.Session, *models.User, error) {
+	start := time.Now()
+	session, user, err := s.validateSession(ctx, token)
+	metrics.TokenVerification(metrics.KindSession, start, session != nil, err)
+	return session, user, err
+}
+
+func (s *AuthService) validateSession(ctx context.Context, token string) (*models.Session, *models.User, error) {
+	if token == "" {
+		return nil, nil, nil
+	}
+
+	session, err := s.sessions.GetByTokenHash(ctx, hashToken(token))
+	if err != nil {
+		return nil, nil, err
+	}
+	if session == nil {
+		return nil, nil, nil
+	}
+
+	now := time.Now()
+	if !session.ExpiresAt.After(now) {
+		if err := s.sessions.Delete(ctx, session.ID); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, nil
+	}
+
+	user, err := s.users.GetByID(ctx, session.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user == nil {
+		return nil, nil, nil
+	}
+
+	if now.Sub(session.LastSeenAt) >= sessionTouchInterval {
+		session.LastSeenAt = now
+		session.ExpiresAt = s.sessionExpiry(session.CreatedAt, now)
+		if err := s.sessions.Touch(ctx, session.ID, session.LastSeenAt, session.ExpiresAt); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return session, user, nil
+}
+
+// Logout закрывает сессию по значению cookie. Неизвестная сессия - не ошибка.
+func (s *AuthService) Logout(ctx context.Context, token string) error {
+	if token == "" {
+		return nil
+	}
+	session, err := s.sessions.GetByTokenHash(ctx, hashToken(token))
+	if err != nil {
+		return err
+	}
+	if session == nil {
+		return nil
+	}
+	if err := s.sessions.Delete(ctx, session.ID); err != nil {
+		return err
+	}
+	s.revokeTokens(session.TokenHash)
+	s.audit.Record(ctx, &models.AuditEvent{
+		Type:    AuditLogout,
+		ActorID: session.UserID,
+		Details: map[string]string{"session_id": session.ID},
+	})
+	return nil
+}
+
+// ListSessions возвращает действующие сессии пользователя, новые первыми
+func (s *AuthService) ListSessions(ctx context.Context, user *models.User) ([]*models.Session, error) {
+	sessions, err := s.sessions.ListByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	active := sessions[:0]
+	for _, session := range sessions {
+		if session.ExpiresAt.After(now) {
+			active = append(active, session)
+		}
+	}
+	return active, nil
+}
+
+// RevokeSession закрывает одну сессию пользователя (выход на другом устройстве).
+// Чужая или неизвестная сессия - ErrSessionNotFound.
+func (s *AuthService) RevokeSession(ctx context.Context, user *models.User, id string) error {
+	ok, err := s.sessions.DeleteForUser(ctx, user.ID, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrSessionNotFound
+	}
+	s.revokeSubject(user)
+	s.record(ctx, AuditSessionRevoked, user, "", map[string]string{"session_id": id})
+	return nil
+}
+
+// RevokeOtherSessions закрывает все сессии пользователя, кроме текущей, и возвращает их количество
+func (s *AuthService) RevokeOtherSessions(ctx context.Context, user *models.User, currentID string) (int64, error) {
+	n, err := s.sessions.DeleteOthers(ctx, user.ID, currentID)
+	if err != nil {
+		return 0, err
+	}
+	s.revokeSubject(user)
+	s.record(ctx, AuditSessionsRevoked, user, "", map[string]string{
+		"scope":   "others",
+		"revoked": strconv.FormatInt(n, 10),
+	})
+	return n, nil
+}
+
+// RevokeSessions закрывает все сессии пользователя и возвращает их количество
+func (s *AuthService) RevokeSessions(ctx context.Context, username string) (int64, error) {
+	user, err := s.users.GetByUsername(ctx, normalizeUsername(username))
+	if err != nil {
+		return 0, err
+	}
+	if user == nil {
+		return 0, ErrUserNotFound
+	}
+	n, err := s.sessions.DeleteByUserID(ctx, user.ID)
+	if err != nil {
+		return 0, err
+	}
+	s.revokeSubject(user)
+	s.record(ctx, AuditSessionsRevoked, user, "", map[string]string{
+		"scope":   "all",
+		"revoked": strconv.FormatInt(n, 10),
+	})
+	return n, nil
+}
+
+// ActiveSessions возвращает число действующих (не истёкших) сессий
+func (s *AuthService) ActiveSessions(ctx context.Context) (int64, error) {
+	return s.sessions.CountActive(ctx, time.Now())
+}
+
+// PurgeExpiredSessions удаляет истёкшие сессии из хранилища
+func (s *AuthService) PurgeExpiredSessions(ctx context.Context) (int64, error) {
+	return s.sessions.DeleteExpired(ctx, time.Now())
+}
+
+// sessionExpiry - now + TTL, но не позже createdAt + MaxLifetime
+func (s *AuthService) sessionExpiry(createdAt, now time.Time) time.Time {
+	expiresAt := now.Add(s.cfg.Session.TTL)
+	if limit := createdAt.Add(s.cfg.Session.MaxLifetime); expiresAt.After(limit) {
+		return limit
+	}
+	return expiresAt
+}
+
+// truncateUserAgent обрезает User-Agent до maxUserAgentLength символов, не разрывая UTF-8
+func truncateUserAgent(ua string) string {
+	if utf8.RuneCountInString(ua) <= maxUserAgentLength {
+		return ua
+	}
+	return string([]rune(ua)[:maxUserAgentLength])
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/metrics"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/auth/internal/models"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/shared/jwks"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+)
+
+// TokenConfig - параметры выпуска access- и refresh-токенов
+type TokenConfig struct {
+	Issuer     string
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+}
+
+// TokenPair - ответ на выдачу или обновление токенов
+type TokenPair struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
+
+// IssueAccessToken выпускает подписанный JWT (EdDSA) для пользователя
+func (s *AuthService) IssueAccessToken(user *models.User) (string, time.Time, error) {
+	key, err := s.keys.signingKey()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.cfg.Token.AccessTTL)
+	claims := jwks.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    s.cfg.Token.Issuer,
+			Subject:   user.Username,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			ID:        uuid.New().String(),
+		},
+		Roles:       user.Roles,
+		Permissions: Permissions(user.Roles),
+		// Токен, полученный по паролю, действует от имени пользователя целиком
+		Scope: strings.Join(AllScopes, " "),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	token.Header["kid"] = key.kid
+	signed, err := token.SignedString(key.private)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
+}
+
+// IssueToken проверяет учётные данные и выпускает пару токенов (для API и скриптов).
+// Для пользователей с 2FA обязателен TOTP-код (otp). Каждый такой вход открывает
+// новое семейство refresh-токенов.
+func (s *AuthService) IssueToken(ctx context.Context, username, password, otp string) (*TokenPair, error) {
+	user, err := s.Login(ctx, username, password)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		if otp == "" {
+			return nil, ErrMFARequired
+		}
+		if err := s.verifySecondFactor(ctx, user, otp, ""); err != nil {
+			if errors.Is(err, ErrInvalidOTP) {
+				if ferr := s.secondFactorFailed(ctx, user); ferr != nil {
+					return nil, ferr
+				}
+			}
+			return nil, err
+		}
+		if err := s.guard.Success(ctx, user.Username); err != nil {
+			return nil, err
+		}
+	}
+	pair, err := s.issueTokenPair(ctx, user, uuid.New().String())
+	if err != nil {
+		return nil, err
+	}
+	metrics.LoginAttempt(metrics.LoginSuccess)
+	s.record(ctx, AuditTokenIssued, user, "", nil)
+	return pair, nil
+}
+
+// Refresh обменивает refresh-токен на новую пару (ротация).
+// Повторное предъявление уже использованного токена означает его утечку:
+// отзывается всё семейство, включая токен, выданный легитимному клиенту.
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	if refreshToken == "" {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	stored, err := s.refreshTokens.GetByTokenHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil || stored.RevokedAt != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	now := time.Now()
+	if stored.UsedAt != nil {
+		if err := s.refreshTokens.RevokeFamily(ctx, stored.FamilyID, now); err != nil {
+			return nil, err
+		}
+		s.recordRefreshReuse(ctx, stored)
+		return nil, ErrRefreshTokenReused
+	}
+	if !stored.ExpiresAt.After(now) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// Атомарная отметка защищает от двух одновременных обменов одного токена
+	marked, err := s.refreshTokens.MarkUsed(ctx, stored.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !marked {
+		if err := s.refreshTokens.RevokeFamily(ctx, stored.FamilyID, now); err != nil {
+			return nil, err
+		}
+		s.recordRefreshReuse(ctx, stored)
+		return nil, ErrRefreshTokenReused
+	}
+
+	user, err := s.users.GetByID(ctx, stored.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	pair, err := s.issueTokenPair(ctx, user, stored.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+	s.record(ctx, AuditTokenRefreshed, user, "", map[string]string{"family_id": stored.FamilyID})
+	return pair, nil
+}
+
+// recordRefreshReuse фиксирует повторное предъявление refresh-токена (вероятная утечка)
+func (s *AuthService) recordRefreshReuse(ctx context.Context, stored *models.RefreshToken) {
+	s.audit.Record(ctx, &models.AuditEvent{
+		Type:    AuditRefreshTokenReused,
+		ActorID: stored.UserID,
+		Details: map[string]string{"family_id": stored.FamilyID},
+	})
+}
+
+// issueTokenPair выпускает access-токен и новый refresh-токен в указанном семействе
+func (s *AuthService) issueTokenPair(ctx context.Context, user *models.User, familyID string) (*TokenPair, error) {
+	accessToken, accessExpiresAt, err := s.IssueAccessToken(user)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := generateToken()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	stored := &models.RefreshToken{
+		ID:        "rt_" + uuid.New().String(),
+		FamilyID:  familyID,
+		UserID:    user.ID,
+		TokenHash: hashToken(refreshToken),
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.cfg.Token.RefreshTTL),
+	}
+	if err := s.refreshTokens.Create(ctx, stored); err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:      accessToken,
+		AccessExpiresAt:  accessExpiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: stored.ExpiresAt,
+	}, nil
+}
+
+// parseAccessToken проверяет подпись, срок действия и издателя JWT
+func (s *AuthService) parseAccessToken(token string) (*jwks.Claims, error) {
+	claims := &jwks.Claims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		pub, ok := s.keys.publicKey(kid)
+		if !ok {
+			return nil, errors.New("unknown signing key")
+		}
+		return pub, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(s.cfg.Token.Issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// JWKS возвращает открытые ключи для проверки токенов
+func (s *AuthService) JWKS() jwks.Set {
+	return s.keys.JWKS()
+}
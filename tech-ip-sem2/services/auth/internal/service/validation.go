@@ -0,0 +1,102 @@
+package service
+
+import (
+	"net/mail"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const (
+	minPasswordLength = 8
+	maxPasswordLength = 72 // bcrypt учитывает только первые 72 байта
+)
+
+var usernamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{2,31}$`)
+
+// FieldError - ошибка валидации конкретного поля запроса
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError содержит все найденные ошибки валидации
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Field + ": " + f.Message
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) add(field, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: message})
+}
+
+// validateRegistration проверяет уже нормализованные username и email
+func validateRegistration(username, email, password string) *ValidationError {
+	verr := &ValidationError{}
+
+	if !usernamePattern.MatchString(username) {
+		verr.add("username", "must be 3-32 characters: latin letters, digits, '_', '.', '-'")
+	}
+
+	if email == "" {
+		verr.add("email", "is required")
+	} else if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+		verr.add("email", "is not a valid email address")
+	}
+
+	validatePassword(verr, username, password)
+
+	if len(verr.Fields) > 0 {
+		return verr
+	}
+	return nil
+}
+
+// validateCredentials - проверки validateRegistration без email (для начальных пользователей)
+func validateCredentials(username, password string) *ValidationError {
+	verr := &ValidationError{}
+	if !usernamePattern.MatchString(username) {
+		verr.add("username", "must be 3-32 characters: latin letters, digits, '_', '.', '-'")
+	}
+	validatePassword(verr, username, password)
+
+	if len(verr.Fields) > 0 {
+		return verr
+	}
+	return nil
+}
+
+// validatePassword применяет парольную политику
+func validatePassword(verr *ValidationError, username, password string) {
+	if len(password) < minPasswordLength {
+		verr.add("password", "must be at least 8 characters long")
+		return
+	}
+	if len(password) > maxPasswordLength {
+		verr.add("password", "must be at most 72 bytes long")
+		return
+	}
+
+	var hasLetter, hasDigit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	if !hasLetter || !hasDigit {
+		verr.add("password", "must contain at least one letter and one digit")
+	}
+	if username != "" && strings.Contains(strings.ToLower(password), username) {
+		verr.add("password", "must not contain the username")
+	}
+}
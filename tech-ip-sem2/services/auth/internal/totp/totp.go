@@ -0,0 +1,121 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Algorithm - хеш-функция HMAC (RFC 6238 допускает SHA1, SHA256, SHA512)
+type Algorithm string
+
+const (
+	SHA1   Algorithm = "SHA1"
+	SHA256 Algorithm = "SHA256"
+	SHA512 Algorithm = "SHA512"
+)
+
+// Params - параметры генерации кодов
+type Params struct {
+	Digits    int
+	Period    time.Duration
+	Algorithm Algorithm
+}
+
+// DefaultParams совместимы с Google Authenticator и большинством приложений
+var DefaultParams = Params{Digits: 6, Period: 30 * time.Second, Algorithm: SHA1}
+
+var b32 = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret возвращает случайный 160-битный секрет в base32 (без паддинга)
+func GenerateSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return b32.EncodeToString(b), nil
+}
+
+// DecodeSecret декодирует base32-секрет (регистр и пробелы не важны)
+func DecodeSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	return b32.DecodeString(strings.TrimRight(secret, "="))
+}
+
+// Step - номер временного шага для момента t
+func Step(t time.Time, p Params) int64 {
+	return t.Unix() / int64(p.Period/time.Second)
+}
+
+// HOTP вычисляет код по RFC 4226 для счётчика counter
+func HOTP(key []byte, counter int64, p Params) string {
+	mac := hmac.New(p.hashFunc(), key)
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(counter))
+	mac.Write(buf[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation (RFC 4226, раздел 5.3)
+	offset := sum[len(sum)-1] & 0x0f
+	bin := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < p.Digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", p.Digits, bin%mod)
+}
+
+// Code вычисляет TOTP-код (RFC 6238) для момента t
+func Code(key []byte, t time.Time, p Params) string {
+	return HOTP(key, Step(t, p), p)
+}
+
+// Validate проверяет код с допуском ±skew шагов на расхождение часов.
+// Возвращает номер совпавшего шага, чтобы вызывающий мог запретить его повторное использование.
+func Validate(key []byte, code string, t time.Time, skew int, p Params) (int64, bool) {
+	if len(code) != p.Digits {
+		return 0, false
+	}
+	current := Step(t, p)
+	for i := -skew; i <= skew; i++ {
+		step := current + int64(i)
+		if subtle.ConstantTimeCompare([]byte(HOTP(key, step, p)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// URI формирует otpauth:// ссылку для QR-кода (формат Key Uri Format)
+func URI(issuer, account, secret string, p Params) string {
+	label := url.PathEscape(issuer + ":" + account)
+	q := url.Values{}
+	q.Set("secret", secret)
+	q.Set("issuer", issuer)
+	q.Set("algorithm", string(p.Algorithm))
+	q.Set("digits", fmt.Sprint(p.Digits))
+	q.Set("period", fmt.Sprint(int64(p.Period/time.Second)))
+	return "otpauth://totp/" + label + "?" + q.Encode()
+}
+
+func (p Params) hashFunc() func() hash.Hash {
+	switch p.Algorithm {
+	case SHA256:
+		return sha256.New
+	case SHA512:
+		return sha512.New
+	default:
+		return sha1.New
+	}
+}
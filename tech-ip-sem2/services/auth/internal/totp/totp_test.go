@@ -0,0 +1,61 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// Тестовые векторы RFC 6238, приложение B (SHA1, 8 цифр, шаг 30 секунд)
+var rfc6238SHA1 = []struct {
+	unix int64
+	code string
+}{
+	{59, "94287082"},
+	{1111111109, "07081804"},
+	{1111111111, "14050471"},
+	{1234567890, "89005924"},
+	{2000000000, "69279037"},
+	{20000000000, "65353130"},
+}
+
+var rfc6238Params = Params{Digits: 8, Period: 30 * time.Second, Algorithm: SHA1}
+
+// rfc6238Key - ASCII-секрет "12345678901234567890" из RFC 6238
+var rfc6238Key = []byte("12345678901234567890")
+
+func TestCodeRFC6238(t *testing.T) {
+	for _, tc := range rfc6238SHA1 {
+		if got := Code(rfc6238Key, time.Unix(tc.unix, 0), rfc6238Params); got != tc.code {
+			t.Errorf("Code(t=%d) = %s, want %s", tc.unix, got, tc.code)
+		}
+	}
+}
+
+func TestValidateRFC6238(t *testing.T) {
+	for _, tc := range rfc6238SHA1 {
+		now := time.Unix(tc.unix, 0)
+		step, ok := Validate(rfc6238Key, tc.code, now, 0, rfc6238Params)
+		if !ok || step != Step(now, rfc6238Params) {
+			t.Errorf("Validate(t=%d) = (%d, %v), want (%d, true)", tc.unix, step, ok, Step(now, rfc6238Params))
+		}
+		// Код соседнего шага принимается только с допуском skew
+		next := now.Add(30 * time.Second)
+		if _, ok := Validate(rfc6238Key, tc.code, next, 0, rfc6238Params); ok {
+			t.Errorf("Validate(t=%d+30s, skew=0) accepted previous step", tc.unix)
+		}
+		if _, ok := Validate(rfc6238Key, tc.code, next, 1, rfc6238Params); !ok {
+			t.Errorf("Validate(t=%d+30s, skew=1) rejected previous step", tc.unix)
+		}
+	}
+}
+
+func TestDecodeSecretRFC6238(t *testing.T) {
+	// Тот же секрет в base32, как его передают приложению-аутентификатору
+	key, err := DecodeSecret("gezd gnbv gy3t qojq gezd gnbv gy3t qojq")
+	if err != nil {
+		t.Fatalf("DecodeSecret: %v", err)
+	}
+	if string(key) != string(rfc6238Key) {
+		t.Fatalf("DecodeSecret = %q, want %q", key, rfc6238Key)
+	}
+}
@@ -1,17 +1,19 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"time"
 
+	healthcheck "github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/shared/health"
 	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/shared/logger"
 	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/shared/middleware"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/shared/server"
 	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/tasks/internal/client/authclient"
 	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/tasks/internal/config"
 	handlers "github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/tasks/internal/http"
 	customMiddleware "github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/tasks/internal/middleware"
-	metricsMiddleware "github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/tasks/internal/middleware"
 	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/tasks/internal/repository"
 	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/tasks/internal/service"
 )
@@ -24,6 +26,17 @@ func main() {
 		logrusLogger.WithError(err).Fatal("failed to load config")
 	}
 
+	if cfg.CSRFSecret == config.DefaultCSRFSecret {
+		logrusLogger.Warn("CSRF_SECRET is not set, using insecure development secret")
+	}
+
+	// Проверки готовности для /readyz
+	health := healthcheck.New(logrusLogger)
+
+	// Серверы, остановка по сигналу и освобождение ресурсов
+	runner := server.New(logrusLogger, cfg.ShutdownTimeout)
+	runner.OnStop(health.SetStopping)
+
 	// Инициализация репозитория
 	var repo repository.TaskRepository
 	if cfg.DB.Driver == "postgres" {
@@ -31,45 +44,122 @@ func main() {
 		if err != nil {
 			logrusLogger.WithError(err).Fatal("failed to connect to database")
 		}
-		defer postgresRepo.Close()
+		runner.Close("database", postgresRepo.Close)
+		health.AddCheck("database", postgresRepo.Ping)
 		repo = postgresRepo
 	} else {
 		logrusLogger.Fatal("unsupported database driver: " + cfg.DB.Driver)
 	}
 
-	// Инициализация клиента Auth (для совместимости, но теперь не используется)
-	authClient, err := authclient.NewClient(cfg.AuthGRPCAddr, 2*time.Second, logrusLogger)
+	// Инициализация клиента Auth (проверка сессий). Соединение устанавливается лениво,
+	// поэтому tasks стартует и при недоступном auth service
+	res := cfg.AuthResilience
+	authClient, err := authclient.NewClient(cfg.AuthGRPCAddr, authclient.Options{
+		TLS: authclient.TLSConfig{
+			Enabled:    cfg.AuthGRPCTLS.Enabled,
+			CAFile:     cfg.AuthGRPCTLS.CAFile,
+			CertFile:   cfg.AuthGRPCTLS.CertFile,
+			KeyFile:    cfg.AuthGRPCTLS.KeyFile,
+			ServerName: cfg.AuthGRPCTLS.ServerName,
+		},
+		Timeout: res.Timeout,
+		Retry: authclient.RetryConfig{
+			MaxAttempts: res.MaxAttempts,
+			BaseDelay:   res.RetryBaseDelay,
+			MaxDelay:    res.RetryMaxDelay,
+		},
+		Breaker: authclient.BreakerConfig{
+			FailureThreshold: res.FailureThreshold,
+			OpenTimeout:      res.OpenTimeout,
+		},
+		Cache: authclient.CacheConfig{
+			Size:        cfg.AuthCache.Size,
+			TTL:         cfg.AuthCache.TTL,
+			NegativeTTL: cfg.AuthCache.NegativeTTL,
+		},
+	}, logrusLogger)
 	if err != nil {
 		logrusLogger.WithError(err).Fatal("failed to create auth client")
 	}
-	defer authClient.Close()
+	runner.Close("auth client", authClient.Close)
+
+	// Отзывы сессий и токенов из auth service сбрасывают закешированные проверки
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	runner.OnStop(stopWatch)
+	go authClient.WatchRevocations(watchCtx)
+
+	// Локальная проверка access-токенов по закешированному JWKS (без gRPC на каждый запрос)
+	if cfg.AuthJWKSURL != "" {
+		authClient.UseJWKS(authclient.NewJWKSVerifier(cfg.AuthJWKSURL, cfg.AuthIssuer, 5*time.Minute, logrusLogger))
+		logrusLogger.WithField("jwks_url", cfg.AuthJWKSURL).Info("local JWT verification enabled")
+	}
 
 	// Инициализация сервиса
 	taskService := service.NewTaskService(repo)
 
+	// Задачи, удалённые пользователями, хранятся TASKS_DELETED_RETENTION и затем удаляются окончательно
+	if cfg.DeletedRetention > 0 {
+		go func() {
+			ticker := time.NewTicker(time.Hour)
+			defer ticker.Stop()
+			for range ticker.C {
+				n, err := taskService.PurgeDeleted(context.Background(), cfg.DeletedRetention)
+				if err != nil {
+					logrusLogger.WithError(err).Warn("failed to purge deleted tasks")
+				} else {
+					logrusLogger.WithField("count", n).Debug("deleted tasks purged")
+				}
+			}
+		}()
+	}
+
 	// Инициализация хендлера
 	taskHandler := handlers.NewTaskHandler(taskService, authClient, logrusLogger)
 
 	// Настройка роутера
+	// Маршруты из AUTH_FAIL_OPEN_ROUTES обслуживаются и при недоступном auth service
+	failOpen := make(map[string]bool, len(res.FailOpenRoutes))
+	for _, route := range res.FailOpenRoutes {
+		failOpen[route] = true
+	}
+
 	mux := http.NewServeMux()
-	mux.HandleFunc("POST /v1/tasks", taskHandler.CreateTask)
-	mux.HandleFunc("GET /v1/tasks", taskHandler.ListTasks)
-	mux.HandleFunc("GET /v1/tasks/{id}", taskHandler.GetTask)
-	mux.HandleFunc("PATCH /v1/tasks/{id}", taskHandler.UpdateTask)
-	mux.HandleFunc("DELETE /v1/tasks/{id}", taskHandler.DeleteTask)
-	mux.HandleFunc("GET /v1/tasks/search", taskHandler.SearchTasks)
-	mux.Handle("GET /metrics", metricsMiddleware.MetricsHandler())
-
-	// Цепочка middleware (порядок важен!)
-	handler := middleware.RequestIDMiddleware(mux)                // 1. request-id
-	handler = customMiddleware.SecurityHeadersMiddleware(handler) // 2. заголовки безопасности
-	handler = customMiddleware.CSRFMiddleware(handler)            // 3. CSRF защита
-	handler = metricsMiddleware.MetricsMiddleware(handler)        // 4. метрики
-	handler = middleware.LoggingMiddleware(handler)               // 5. логирование
-
-	addr := fmt.Sprintf(":%s", cfg.TasksPort)
-	logrusLogger.WithField("port", cfg.TasksPort).Info("tasks service starting")
-	if err := http.ListenAndServe(addr, handler); err != nil {
-		logrusLogger.WithError(err).Fatal("server failed")
+	route := func(pattern string, h http.Handler) {
+		policy := handlers.FailClosed
+		if failOpen[pattern] {
+			policy = handlers.FailOpen
+			delete(failOpen, pattern)
+			logrusLogger.WithField("route", pattern).Warn("route is fail-open when auth service is unavailable")
+		}
+		mux.Handle(pattern, handlers.WithFailPolicy(policy, h))
+	}
+	route("POST /v1/tasks", http.HandlerFunc(taskHandler.CreateTask))
+	route("GET /v1/tasks", http.HandlerFunc(taskHandler.ListTasks))
+	route("GET /v1/tasks/{id}", http.HandlerFunc(taskHandler.GetTask))
+	route("PATCH /v1/tasks/{id}", http.HandlerFunc(taskHandler.UpdateTask))
+	route("DELETE /v1/tasks/{id}", http.HandlerFunc(taskHandler.DeleteTask))
+	route("GET /v1/tasks/search", http.HandlerFunc(taskHandler.SearchTasks))
+	route("GET /v1/admin/tasks", http.HandlerFunc(taskHandler.AdminListTasks))
+	route("DELETE /v1/admin/tasks/{id}", http.HandlerFunc(taskHandler.AdminDeleteTask))
+	route("GET /metrics", middleware.MetricsHandler())
+	route("GET /healthz", http.HandlerFunc(health.Healthz))
+	route("GET /readyz", http.HandlerFunc(health.Readyz))
+
+	for pattern := range failOpen {
+		logrusLogger.WithField("route", pattern).Fatal("unknown route in AUTH_FAIL_OPEN_ROUTES")
+	}
+
+	// Цепочка middleware (порядок важен!): оборачиваем изнутри наружу,
+	// поэтому request-id подключается последним и выполняется первым
+	var handler http.Handler = mux
+	handler = customMiddleware.CSRFMiddleware([]byte(cfg.CSRFSecret), logrusLogger)(handler) // 5. CSRF защита
+	handler = customMiddleware.SecurityHeadersMiddleware(handler)                            // 4. заголовки безопасности
+	handler = middleware.MetricsMiddleware(mux)(handler)                                     // 3. метрики
+	handler = middleware.LoggingMiddleware(handler)                                          // 2. логирование
+	handler = middleware.RequestIDMiddleware(handler)                                        // 1. request-id
+
+	runner.HTTP("tasks-http", server.NewHTTPServer(fmt.Sprintf(":%s", cfg.TasksPort), handler))
+	if err := runner.Run(); err != nil {
+		logrusLogger.WithError(err).Fatal("tasks service stopped with error")
 	}
 }
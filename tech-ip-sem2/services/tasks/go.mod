@@ -3,6 +3,7 @@ module github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/tasks
 go 1.22
 
 require (
+	github.com/golang-jwt/jwt/v5 v5.2.2
 	github.com/google/uuid v1.6.0
 	github.com/lib/pq v1.10.9
 	github.com/prometheus/client_golang v1.20.5
@@ -10,6 +11,7 @@ require (
 	github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/proto v0.0.0
 	github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/shared v0.0.0
 	google.golang.org/grpc v1.64.0
+	google.golang.org/protobuf v1.34.2
 )
 
 require (
@@ -24,7 +26,6 @@ require (
 	golang.org/x/sys v0.22.0 // indirect
 	golang.org/x/text v0.16.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20240318140521-94a12d6c2237 // indirect
-	google.golang.org/protobuf v1.34.2 // indirect
 )
 
 replace (
@@ -0,0 +1,154 @@
+package authclient
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrCircuitOpen - auth service недавно был недоступен, вызов не выполнялся
+var ErrCircuitOpen = errors.New("auth service circuit breaker is open")
+
+// Состояния circuit breaker (значения метрики auth_client_circuit_state)
+const (
+	circuitClosed   = 0 // вызовы проходят
+	circuitHalfOpen = 1 // пропускается один пробный вызов
+	circuitOpen     = 2 // вызовы сразу завершаются ErrCircuitOpen
+)
+
+var circuitStateNames = map[int]string{
+	circuitClosed:   "closed",
+	circuitHalfOpen: "half_open",
+	circuitOpen:     "open",
+}
+
+var (
+	circuitState = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "auth_client_circuit_state",
+			Help: "State of the auth service circuit breaker (0 - closed, 1 - half-open, 2 - open)",
+		},
+	)
+
+	circuitTransitions = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "auth_client_circuit_transitions_total",
+			Help: "Total number of auth service circuit breaker state changes",
+		},
+		[]string{"to"},
+	)
+)
+
+// BreakerConfig - параметры circuit breaker
+type BreakerConfig struct {
+	FailureThreshold int           // подряд неудачных вызовов до размыкания; 0 - breaker выключен
+	OpenTimeout      time.Duration // сколько ждать до пробного вызова
+}
+
+// breaker размыкается после FailureThreshold неудач подряд и быстро отклоняет вызовы.
+// По истечении OpenTimeout пропускает один пробный вызов: успех замыкает цепь, неудача снова размыкает.
+type breaker struct {
+	cfg    BreakerConfig
+	logger *logrus.Logger
+
+	mu       sync.Mutex
+	state    int
+	failures int
+	openedAt time.Time
+	probing  bool // пробный вызов в полуоткрытом состоянии уже выполняется
+}
+
+func newBreaker(cfg BreakerConfig, logger *logrus.Logger) *breaker {
+	circuitState.Set(circuitClosed)
+	return &breaker{cfg: cfg, logger: logger}
+}
+
+// allow сообщает, можно ли выполнить вызов
+func (b *breaker) allow() error {
+	if b.cfg.FailureThreshold <= 0 {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		if time.Since(b.openedAt) < b.cfg.OpenTimeout {
+			return ErrCircuitOpen
+		}
+		b.setState(circuitHalfOpen)
+		b.probing = true
+		return nil
+	case circuitHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+		return nil
+	}
+	return nil
+}
+
+// success - auth service ответил (в том числе отказом в доступе)
+func (b *breaker) success() {
+	if b.cfg.FailureThreshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.probing = false
+	if b.state != circuitClosed {
+		b.setState(circuitClosed)
+	}
+}
+
+// failure - auth service недоступен или не ответил вовремя
+func (b *breaker) failure() {
+	if b.cfg.FailureThreshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.state == circuitHalfOpen || b.failures >= b.cfg.FailureThreshold {
+		b.openedAt = time.Now()
+		if b.state != circuitOpen {
+			b.setState(circuitOpen)
+		}
+	}
+}
+
+// release завершает вызов, не повлиявший на состояние (например, отменённый клиентом)
+func (b *breaker) release() {
+	if b.cfg.FailureThreshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+func (b *breaker) setState(state int) {
+	b.state = state
+	circuitState.Set(float64(state))
+	circuitTransitions.WithLabelValues(circuitStateNames[state]).Inc()
+
+	entry := b.logger.WithFields(logrus.Fields{
+		"component": "auth_client",
+		"state":     circuitStateNames[state],
+	})
+	if state == circuitOpen {
+		entry.Warn("auth service circuit breaker opened")
+	} else {
+		entry.Info("auth service circuit breaker state changed")
+	}
+}
@@ -0,0 +1,172 @@
+package authclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const testOpenTimeout = 30 * time.Millisecond
+
+// breakerStep - одно действие над breaker и ожидаемое состояние после него
+type breakerStep struct {
+	op        string // allow, success, failure, release, wait
+	wantErr   error  // только для allow
+	wantState int
+}
+
+func TestBreakerTransitions(t *testing.T) {
+	cases := []struct {
+		name      string
+		threshold int
+		steps     []breakerStep
+	}{
+		{
+			name:      "opens after threshold consecutive failures",
+			threshold: 2,
+			steps: []breakerStep{
+				{op: "allow", wantState: circuitClosed},
+				{op: "failure", wantState: circuitClosed},
+				{op: "allow", wantState: circuitClosed},
+				{op: "failure", wantState: circuitOpen},
+				{op: "allow", wantErr: ErrCircuitOpen, wantState: circuitOpen},
+			},
+		},
+		{
+			name:      "success resets failure count",
+			threshold: 2,
+			steps: []breakerStep{
+				{op: "failure", wantState: circuitClosed},
+				{op: "success", wantState: circuitClosed},
+				{op: "failure", wantState: circuitClosed},
+				{op: "allow", wantState: circuitClosed},
+			},
+		},
+		{
+			name:      "half-open probe success closes",
+			threshold: 1,
+			steps: []breakerStep{
+				{op: "failure", wantState: circuitOpen},
+				{op: "wait", wantState: circuitOpen},
+				{op: "allow", wantState: circuitHalfOpen},
+				{op: "allow", wantErr: ErrCircuitOpen, wantState: circuitHalfOpen}, // пробный вызов только один
+				{op: "success", wantState: circuitClosed},
+				{op: "allow", wantState: circuitClosed},
+			},
+		},
+		{
+			name:      "half-open probe failure reopens",
+			threshold: 3,
+			steps: []breakerStep{
+				{op: "failure", wantState: circuitClosed},
+				{op: "failure", wantState: circuitClosed},
+				{op: "failure", wantState: circuitOpen},
+				{op: "wait", wantState: circuitOpen},
+				{op: "allow", wantState: circuitHalfOpen},
+				{op: "failure", wantState: circuitOpen}, // в полуоткрытом состоянии хватает одной неудачи
+				{op: "allow", wantErr: ErrCircuitOpen, wantState: circuitOpen},
+			},
+		},
+		{
+			name:      "released probe lets next call through",
+			threshold: 1,
+			steps: []breakerStep{
+				{op: "failure", wantState: circuitOpen},
+				{op: "wait", wantState: circuitOpen},
+				{op: "allow", wantState: circuitHalfOpen},
+				{op: "release", wantState: circuitHalfOpen},
+				{op: "allow", wantState: circuitHalfOpen},
+			},
+		},
+		{
+			name:      "disabled breaker never opens",
+			threshold: 0,
+			steps: []breakerStep{
+				{op: "failure", wantState: circuitClosed},
+				{op: "failure", wantState: circuitClosed},
+				{op: "failure", wantState: circuitClosed},
+				{op: "allow", wantState: circuitClosed},
+			},
+		},
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := newBreaker(BreakerConfig{FailureThreshold: tc.threshold, OpenTimeout: testOpenTimeout}, logger)
+			for i, step := range tc.steps {
+				var err error
+				switch step.op {
+				case "allow":
+					err = b.allow()
+				case "success":
+					b.success()
+				case "failure":
+					b.failure()
+				case "release":
+					b.release()
+				case "wait":
+					time.Sleep(testOpenTimeout + 10*time.Millisecond)
+				}
+				if !errors.Is(err, step.wantErr) {
+					t.Fatalf("step %d (%s): error = %v, want %v", i, step.op, err, step.wantErr)
+				}
+				b.mu.Lock()
+				state := b.state
+				b.mu.Unlock()
+				if state != step.wantState {
+					t.Fatalf("step %d (%s): state = %s, want %s", i, step.op, circuitStateNames[state], circuitStateNames[step.wantState])
+				}
+			}
+		})
+	}
+}
+
+// Через клиента: недоступность размыкает цепь, отказ в доступе - нет
+func TestClientBreaker(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+
+	cases := []struct {
+		name      string
+		errs      []error
+		wantCalls int   // вызовов auth service за три проверки
+		wantErr   error // ошибка третьей проверки
+	}{
+		{
+			name:      "unavailable opens circuit",
+			errs:      []error{unavailable, unavailable, unavailable},
+			wantCalls: 2,
+			wantErr:   ErrCircuitOpen,
+		},
+		{
+			name:      "unauthenticated keeps circuit closed",
+			errs:      []error{status.Error(codes.Unauthenticated, "bad"), status.Error(codes.Unauthenticated, "bad")},
+			wantCalls: 3,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := newFakeAuthServer(map[string]string{"sess-1": "alice"})
+			srv.failNext(tc.errs...)
+			c := newTestClient(t, srv, Options{Breaker: BreakerConfig{FailureThreshold: 2, OpenTimeout: time.Minute}})
+
+			var err error
+			for i := 0; i < 3; i++ {
+				_, err = c.VerifySession(context.Background(), "sess-1")
+			}
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("third call error = %v, want %v", err, tc.wantErr)
+			}
+			if got := srv.callCount(); got != tc.wantCalls {
+				t.Errorf("auth service calls = %d, want %d", got, tc.wantCalls)
+			}
+		})
+	}
+}
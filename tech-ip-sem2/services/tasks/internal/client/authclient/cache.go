@@ -0,0 +1,210 @@
+package authclient
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	cacheLookups = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "auth_client_cache_lookups_total",
+			Help: "Total number of verification cache lookups by credential kind and result",
+		},
+		[]string{"kind", "result"},
+	)
+
+	cacheEntries = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "auth_client_cache_entries",
+			Help: "Current number of cached verification results",
+		},
+	)
+
+	cacheInvalidations = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "auth_client_cache_invalidations_total",
+			Help: "Total number of cache entries removed before expiry by reason",
+		},
+		[]string{"reason"},
+	)
+)
+
+// CacheConfig - кеш результатов проверки сессий и токенов
+type CacheConfig struct {
+	Size        int           // максимум записей; 0 - кеш выключен
+	TTL         time.Duration // срок действительных результатов (не дольше срока самих учётных данных)
+	NegativeTTL time.Duration // срок результатов "недействителен"; 0 - не кешируются
+}
+
+type cacheEntry struct {
+	key       string
+	principal *Principal // nil - учётные данные недействительны
+	expiresAt time.Time
+}
+
+// verifyCache - LRU с ограничением по времени жизни записей. Ключ - SHA-256 учётных данных
+// (тот же хеш хранит auth service), поэтому сами токены в памяти не держатся и записи
+// можно удалить по событиям отзыва. Записи действительных результатов индексируются
+// по пользователю для сброса при смене ролей или отзыве всех сессий.
+type verifyCache struct {
+	cfg CacheConfig
+
+	mu        sync.Mutex
+	gen       uint64     // растёт при каждом отзыве, см. put
+	ll        *list.List // от недавно использованных к давно использованным
+	items     map[string]*list.Element
+	bySubject map[string]map[string]*list.Element
+}
+
+func newVerifyCache(cfg CacheConfig) *verifyCache {
+	return &verifyCache{
+		cfg:       cfg,
+		ll:        list.New(),
+		items:     make(map[string]*list.Element),
+		bySubject: make(map[string]map[string]*list.Element),
+	}
+}
+
+// credentialHash - ключ кеша и идентификатор учётных данных в событиях отзыва
+func credentialHash(credential string) string {
+	sum := sha256.Sum256([]byte(credential))
+	return hex.EncodeToString(sum[:])
+}
+
+func (c *verifyCache) enabled() bool {
+	return c.cfg.Size > 0
+}
+
+// get возвращает результат проверки; ok=false - записи нет или она истекла
+func (c *verifyCache) get(kind, key string) (*Principal, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, found := c.items[key]
+	if found && time.Now().Before(el.Value.(*cacheEntry).expiresAt) {
+		c.ll.MoveToFront(el)
+		cacheLookups.WithLabelValues(kind, "hit").Inc()
+		return el.Value.(*cacheEntry).principal, true
+	}
+	if found {
+		c.removeLocked(el)
+		cacheEntries.Set(float64(c.ll.Len()))
+	}
+	cacheLookups.WithLabelValues(kind, "miss").Inc()
+	return nil, false
+}
+
+// generation возвращает номер поколения; его нужно взять до обращения к auth service
+func (c *verifyCache) generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.gen
+}
+
+// put сохраняет результат проверки (principal == nil - учётные данные недействительны).
+// Если с момента generation пришёл отзыв, результат мог устареть и не сохраняется.
+func (c *verifyCache) put(key string, principal *Principal, gen uint64) {
+	now := time.Now()
+	var expiresAt time.Time
+	if principal == nil {
+		if c.cfg.NegativeTTL <= 0 {
+			return
+		}
+		expiresAt = now.Add(c.cfg.NegativeTTL)
+	} else {
+		expiresAt = now.Add(c.cfg.TTL)
+		if !principal.ExpiresAt.IsZero() && principal.ExpiresAt.Before(expiresAt) {
+			expiresAt = principal.ExpiresAt
+		}
+	}
+	if !expiresAt.After(now) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if gen != c.gen {
+		return
+	}
+	if el, ok := c.items[key]; ok {
+		c.removeLocked(el)
+	}
+	el := c.ll.PushFront(&cacheEntry{key: key, principal: principal, expiresAt: expiresAt})
+	c.items[key] = el
+	if principal != nil {
+		keys := c.bySubject[principal.Subject]
+		if keys == nil {
+			keys = make(map[string]*list.Element)
+			c.bySubject[principal.Subject] = keys
+		}
+		keys[key] = el
+	}
+	for c.ll.Len() > c.cfg.Size {
+		c.removeLocked(c.ll.Back())
+	}
+	cacheEntries.Set(float64(c.ll.Len()))
+}
+
+// invalidate удаляет записи отозванных учётных данных
+func (c *verifyCache) invalidate(keys []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	for _, key := range keys {
+		if el, ok := c.items[key]; ok {
+			c.removeLocked(el)
+			cacheInvalidations.WithLabelValues("credential").Inc()
+		}
+	}
+	cacheEntries.Set(float64(c.ll.Len()))
+}
+
+// invalidateSubject удаляет все действительные результаты пользователя
+func (c *verifyCache) invalidateSubject(subject string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	for _, el := range c.bySubject[subject] {
+		c.removeLocked(el)
+		cacheInvalidations.WithLabelValues("subject").Inc()
+	}
+	cacheEntries.Set(float64(c.ll.Len()))
+}
+
+// flush очищает кеш целиком
+func (c *verifyCache) flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	if n := c.ll.Len(); n > 0 {
+		cacheInvalidations.WithLabelValues("flush").Add(float64(n))
+	}
+	c.ll.Init()
+	c.items = make(map[string]*list.Element)
+	c.bySubject = make(map[string]map[string]*list.Element)
+	cacheEntries.Set(0)
+}
+
+func (c *verifyCache) removeLocked(el *list.Element) {
+	entry := el.Value.(*cacheEntry)
+	c.ll.Remove(el)
+	delete(c.items, entry.key)
+	if entry.principal != nil {
+		keys := c.bySubject[entry.principal.Subject]
+		delete(keys, entry.key)
+		if len(keys) == 0 {
+			delete(c.bySubject, entry.principal.Subject)
+		}
+	}
+}
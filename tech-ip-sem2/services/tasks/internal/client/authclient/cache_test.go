@@ -0,0 +1,163 @@
+package authclient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestVerifyCacheTTL(t *testing.T) {
+	cases := []struct {
+		name      string
+		cfg       CacheConfig
+		principal *Principal
+		wait      time.Duration
+		wantHit   bool
+	}{
+		{
+			name:      "valid result before TTL",
+			cfg:       CacheConfig{Size: 10, TTL: time.Minute},
+			principal: &Principal{Subject: "alice"},
+			wantHit:   true,
+		},
+		{
+			name:      "valid result after TTL",
+			cfg:       CacheConfig{Size: 10, TTL: 20 * time.Millisecond},
+			principal: &Principal{Subject: "alice"},
+			wait:      40 * time.Millisecond,
+		},
+		{
+			name:      "credential expiry caps TTL",
+			cfg:       CacheConfig{Size: 10, TTL: time.Minute},
+			principal: &Principal{Subject: "alice", ExpiresAt: time.Now().Add(20 * time.Millisecond)},
+			wait:      40 * time.Millisecond,
+		},
+		{
+			name:    "invalid result before negative TTL",
+			cfg:     CacheConfig{Size: 10, TTL: time.Minute, NegativeTTL: time.Minute},
+			wantHit: true,
+		},
+		{
+			name: "invalid result after negative TTL",
+			cfg:  CacheConfig{Size: 10, TTL: time.Minute, NegativeTTL: 20 * time.Millisecond},
+			wait: 40 * time.Millisecond,
+		},
+		{
+			name: "invalid result not cached without negative TTL",
+			cfg:  CacheConfig{Size: 10, TTL: time.Minute},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newVerifyCache(tc.cfg)
+			c.put("k", tc.principal, c.generation())
+			time.Sleep(tc.wait)
+
+			got, hit := c.get("session", "k")
+			if hit != tc.wantHit {
+				t.Fatalf("hit = %v, want %v", hit, tc.wantHit)
+			}
+			if hit && got != tc.principal {
+				t.Fatalf("principal = %+v, want %+v", got, tc.principal)
+			}
+		})
+	}
+}
+
+func TestVerifyCacheLRUEviction(t *testing.T) {
+	c := newVerifyCache(CacheConfig{Size: 2, TTL: time.Minute})
+	put := func(key, subject string) { c.put(key, &Principal{Subject: subject}, c.generation()) }
+
+	put("a", "alice")
+	put("b", "bob")
+	c.get("session", "a") // a становится недавно использованной
+	put("c", "carol")     // вытесняет b
+
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, hit := c.get("session", key); hit != want {
+			t.Errorf("get(%s) hit = %v, want %v", key, hit, want)
+		}
+	}
+	if _, ok := c.bySubject["bob"]; ok {
+		t.Error("evicted entry is still indexed by subject")
+	}
+}
+
+// Результат, полученный до отзыва, не должен попасть в кеш после него
+func TestVerifyCacheGeneration(t *testing.T) {
+	cases := []struct {
+		name   string
+		revoke func(c *verifyCache)
+	}{
+		{"credential revoked", func(c *verifyCache) { c.invalidate([]string{"k"}) }},
+		{"subject revoked", func(c *verifyCache) { c.invalidateSubject("alice") }},
+		{"cache flushed", func(c *verifyCache) { c.flush() }},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newVerifyCache(CacheConfig{Size: 10, TTL: time.Minute})
+			gen := c.generation()
+			tc.revoke(c) // отзыв пришёл, пока шёл вызов auth service
+			c.put("k", &Principal{Subject: "alice"}, gen)
+			if _, hit := c.get("session", "k"); hit {
+				t.Fatal("stale result was cached")
+			}
+
+			c.put("k", &Principal{Subject: "alice"}, c.generation())
+			if _, hit := c.get("session", "k"); !hit {
+				t.Fatal("result with current generation was not cached")
+			}
+		})
+	}
+}
+
+func TestClientCache(t *testing.T) {
+	cases := []struct {
+		name       string
+		cfg        CacheConfig
+		credential string
+		errs       []error
+		wantCalls  int // вызовов auth service за две проверки
+	}{
+		{
+			name:       "valid session served from cache",
+			cfg:        CacheConfig{Size: 10, TTL: time.Minute},
+			credential: "sess-1",
+			wantCalls:  1,
+		},
+		{
+			name:       "invalid session served from negative cache",
+			cfg:        CacheConfig{Size: 10, TTL: time.Minute, NegativeTTL: time.Minute},
+			credential: "sess-unknown",
+			wantCalls:  1,
+		},
+		{
+			name:       "errors are not cached",
+			cfg:        CacheConfig{Size: 10, TTL: time.Minute, NegativeTTL: time.Minute},
+			credential: "sess-1",
+			errs:       []error{status.Error(codes.Internal, "boom")},
+			wantCalls:  2,
+		},
+		{
+			name:       "disabled cache",
+			credential: "sess-1",
+			wantCalls:  2,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := newFakeAuthServer(map[string]string{"sess-1": "alice"})
+			srv.failNext(tc.errs...)
+			c := newTestClient(t, srv, Options{Cache: tc.cfg})
+
+			c.VerifySession(context.Background(), tc.credential)
+			c.VerifySession(context.Background(), tc.credential)
+			if got := srv.callCount(); got != tc.wantCalls {
+				t.Errorf("auth service calls = %d, want %d", got, tc.wantCalls)
+			}
+		})
+	}
+}
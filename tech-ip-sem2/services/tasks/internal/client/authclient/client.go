@@ -2,7 +2,9 @@ package authclient
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -14,23 +16,78 @@ import (
 	"google.golang.org/grpc/status"
 )
 
+// Principal - проверенная auth service личность пользователя
+type Principal struct {
+	Subject     string
+	Roles       []string
+	Permissions []string // разрешения, которые дают роли пользователя
+	Scopes      []string // scopes Bearer-токена; у сессии не заполняются
+	ExpiresAt   time.Time
+	Unverified  bool // auth service недоступен, запрос пропущен по политике fail-open
+}
+
+// HasScope сообщает, выдан ли токену scope
+func (p *Principal) HasScope(scope string) bool {
+	return contains(p.Scopes, scope)
+}
+
+// HasPermission сообщает, есть ли у пользователя разрешение
+func (p *Principal) HasPermission(permission string) bool {
+	return contains(p.Permissions, permission)
+}
+
+func contains(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// personalTokenPrefix - персональные токены непрозрачны, их проверяет только auth service
+const personalTokenPrefix = "pat_"
+
+// Options - параметры подключения к auth service
+type Options struct {
+	TLS     TLSConfig
+	Timeout time.Duration // таймаут одной попытки вызова
+	Retry   RetryConfig
+	Breaker BreakerConfig
+	Cache   CacheConfig
+}
+
 type Client struct {
 	conn    *grpc.ClientConn
 	client  pb.AuthServiceClient
 	timeout time.Duration
+	retry   RetryConfig
+	breaker *breaker
+	cache   *verifyCache
 	logger  *logrus.Logger
+	jwks    *JWKSVerifier // если задан, access-токены проверяются локально
 }
 
-func NewClient(addr string, timeout time.Duration, logger *logrus.Logger) (*Client, error) {
-	conn, err := grpc.Dial(addr, grpc.WithInsecure(), grpc.WithBlock())
+// NewClient создаёт клиента без ожидания соединения: оно устанавливается при первом вызове
+// и восстанавливается в фоне, поэтому tasks запускается и при недоступном auth service
+func NewClient(addr string, opts Options, logger *logrus.Logger) (*Client, error) {
+	creds, err := opts.TLS.transportCredentials(addr, logger)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to auth service: %w", err)
+		return nil, fmt.Errorf("auth client TLS: %w", err)
+	}
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth service address: %w", err)
 	}
 
 	return &Client{
 		conn:    conn,
 		client:  pb.NewAuthServiceClient(conn),
-		timeout: timeout,
+		timeout: opts.Timeout,
+		retry:   opts.Retry,
+		breaker: newBreaker(opts.Breaker, logger),
+		cache:   newVerifyCache(opts.Cache),
 		logger:  logger,
 	}, nil
 }
@@ -39,7 +96,91 @@ func (c *Client) Close() error {
 	return c.conn.Close()
 }
 
-func (c *Client) VerifyToken(ctx context.Context, token string) (bool, string, error) {
+// invoke выполняет вызов auth service через circuit breaker с повторами.
+// Ответ auth service (в том числе отказ в доступе) замыкает цепь, недоступность и таймауты - размыкают.
+func (c *Client) invoke(ctx context.Context, method string, fn func(ctx context.Context) error) error {
+	if err := c.breaker.allow(); err != nil {
+		return err
+	}
+
+	err := c.withRetry(ctx, method, fn)
+	switch status.Code(err) {
+	case codes.OK, codes.Unauthenticated, codes.InvalidArgument, codes.NotFound, codes.PermissionDenied:
+		c.breaker.success()
+	case codes.Canceled:
+		c.breaker.release()
+	default:
+		c.breaker.failure()
+	}
+	return err
+}
+
+// cached возвращает закешированный результат проверки или выполняет verify и сохраняет его.
+// Ошибки (недоступность auth service) не кешируются.
+func (c *Client) cached(kind, credential string, verify func() (*Principal, error)) (*Principal, error) {
+	if !c.cache.enabled() {
+		return verify()
+	}
+	key := credentialHash(credential)
+	if principal, ok := c.cache.get(kind, key); ok {
+		return principal, nil
+	}
+
+	gen := c.cache.generation()
+	principal, err := verify()
+	if err == nil {
+		c.cache.put(key, principal, gen)
+	}
+	return principal, err
+}
+
+// callError разбирает ошибку вызова: nil означает, что auth service отверг учётные данные
+// (Unauthenticated), остальное - ошибка недоступности или сбоя auth service
+func callError(logEntry *logrus.Entry, err error) error {
+	if errors.Is(err, ErrCircuitOpen) {
+		logEntry.Debug("auth service call rejected by circuit breaker")
+		return err
+	}
+
+	st, ok := status.FromError(err)
+	if !ok {
+		logEntry.WithError(err).Error("auth service unavailable")
+		return fmt.Errorf("auth service unavailable: %w", err)
+	}
+
+	switch st.Code() {
+	case codes.Unauthenticated:
+		return nil
+	case codes.DeadlineExceeded:
+		logEntry.Warn("auth service timeout")
+		return fmt.Errorf("auth service timeout")
+	default:
+		logEntry.WithFields(logrus.Fields{
+			"code":  st.Code(),
+			"error": st.Message(),
+		}).Error("auth service error")
+		return fmt.Errorf("auth service error: %v", st.Message())
+	}
+}
+
+// UseJWKS включает локальную проверку access-токенов по JWKS вместо gRPC Verify
+func (c *Client) UseJWKS(v *JWKSVerifier) {
+	c.jwks = v
+}
+
+// VerifyToken проверяет Bearer-токен (JWT access-токен или персональный pat_...).
+// Для недействительного токена возвращает (nil, nil).
+// Результаты проверок через auth service кешируются (см. CacheConfig).
+func (c *Client) VerifyToken(ctx context.Context, token string) (*Principal, error) {
+	if c.jwks != nil && !strings.HasPrefix(token, personalTokenPrefix) {
+		return c.jwks.Verify(ctx, token)
+	}
+	return c.cached("token", token, func() (*Principal, error) {
+		return c.verifyToken(ctx, token)
+	})
+}
+
+func (c *Client) verifyToken(ctx context.Context, token string) (*Principal, error) {
 	// Извлекаем request-id из контекста для прокидывания в gRPC метаданные
 	requestID := middleware.GetRequestID(ctx)
 
@@ -50,43 +191,84 @@ func (c *Client) VerifyToken(ctx context.Context, token string) (bool, string, e
 
 	logEntry.Debug("calling auth service Verify")
 
-	// Создаём контекст с таймаутом
-	ctx, cancel := context.WithTimeout(ctx, c.timeout)
-	defer cancel()
-
 	// Прокидываем request-id в метаданные gRPC
 	if requestID != "" {
 		ctx = metadata.AppendToOutgoingContext(ctx, "x-request-id", requestID)
 	}
 
-	resp, err := c.client.Verify(ctx, &pb.VerifyRequest{Token: token})
+	var resp *pb.VerifyResponse
+	err := c.invoke(ctx, "Verify", func(ctx context.Context) (err error) {
+		resp, err = c.client.Verify(ctx, &pb.VerifyRequest{Token: token})
+		return err
+	})
 	if err != nil {
-		st, ok := status.FromError(err)
-		if !ok {
-			logEntry.WithError(err).Error("auth service unavailable")
-			return false, "", fmt.Errorf("auth service unavailable: %w", err)
+		if err := callError(logEntry, err); err != nil {
+			return nil, err
 		}
+		logEntry.WithField("token_present", token != "").Debug("token invalid")
+		return nil, nil
+	}
+
+	if !resp.Valid {
+		return nil, nil
+	}
+
+	logEntry.WithField("subject", resp.Subject).Debug("token verified by auth service")
+
+	return &Principal{
+		Subject:     resp.Subject,
+		Roles:       resp.Roles,
+		Permissions: resp.Permissions,
+		Scopes:      resp.Scopes,
+		ExpiresAt:   resp.ExpiresAt.AsTime(),
+	}, nil
+}
+
+// VerifySession проверяет значение cookie session_id через auth service.
+// Для недействительной сессии возвращает (nil, nil).
+func (c *Client) VerifySession(ctx context.Context, sessionID string) (*Principal, error) {
+	return c.cached("session", sessionID, func() (*Principal, error) {
+		return c.verifySession(ctx, sessionID)
+	})
+}
 
-		switch st.Code() {
-		case codes.Unauthenticated:
-			logEntry.WithField("token_present", token != "").Debug("token invalid")
-			return false, "", nil
-		case codes.DeadlineExceeded:
-			logEntry.Warn("auth service timeout")
-			return false, "", fmt.Errorf("auth service timeout")
-		default:
-			logEntry.WithFields(logrus.Fields{
-				"code":  st.Code(),
-				"error": st.Message(),
-			}).Error("auth service error")
-			return false, "", fmt.Errorf("auth service error: %v", st.Message())
+func (c *Client) verifySession(ctx context.Context, sessionID string) (*Principal, error) {
+	requestID := middleware.GetRequestID(ctx)
+
+	logEntry := c.logger.WithFields(logrus.Fields{
+		"component":  "auth_client",
+		"request_id": requestID,
+	})
+
+	logEntry.Debug("calling auth service VerifySession")
+
+	if requestID != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "x-request-id", requestID)
+	}
+
+	var resp *pb.VerifySessionResponse
+	err := c.invoke(ctx, "VerifySession", func(ctx context.Context) (err error) {
+		resp, err = c.client.VerifySession(ctx, &pb.VerifySessionRequest{SessionId: sessionID})
+		return err
+	})
+	if err != nil {
+		if err := callError(logEntry, err); err != nil {
+			return nil, err
 		}
+		logEntry.Debug("session invalid")
+		return nil, nil
+	}
+
+	if !resp.Valid {
+		return nil, nil
 	}
 
-	logEntry.WithFields(logrus.Fields{
-		"valid":   resp.Valid,
-		"subject": resp.Subject,
-	}).Debug("auth response received")
+	logEntry.WithField("subject", resp.Subject).Debug("session verified by auth service")
 
-	return resp.Valid, resp.Subject, nil
+	return &Principal{
+		Subject:     resp.Subject,
+		Roles:       resp.Roles,
+		Permissions: resp.Permissions,
+		ExpiresAt:   resp.ExpiresAt.AsTime(),
+	}, nil
 }
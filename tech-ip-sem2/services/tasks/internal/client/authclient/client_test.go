@@ -0,0 +1,189 @@
+package authclient
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	pb "github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/proto/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// fakeAuthServer - auth service в памяти: сессии и токены - это одна таблица
+// значение -> subject, ошибки следующих вызовов задаются очередью errs
+type fakeAuthServer struct {
+	pb.UnimplementedAuthServiceServer
+
+	mu       sync.Mutex
+	subjects map[string]string
+	errs     []error
+	calls    int
+	onVerify func() // вызывается внутри каждого вызова, до ответа
+
+	events chan *pb.RevocationEvent
+}
+
+func newFakeAuthServer(subjects map[string]string) *fakeAuthServer {
+	return &fakeAuthServer{subjects: subjects, events: make(chan *pb.RevocationEvent)}
+}
+
+// failNext задаёт ошибки, которыми ответят следующие вызовы
+func (s *fakeAuthServer) failNext(errs ...error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errs = append(s.errs, errs...)
+}
+
+func (s *fakeAuthServer) callCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+// lookup учитывает вызов и возвращает subject или очередную ошибку
+func (s *fakeAuthServer) lookup(credential string) (string, error) {
+	s.mu.Lock()
+	s.calls++
+	onVerify := s.onVerify
+	var err error
+	if len(s.errs) > 0 {
+		err, s.errs = s.errs[0], s.errs[1:]
+	}
+	subject := s.subjects[credential]
+	s.mu.Unlock()
+
+	if onVerify != nil {
+		onVerify()
+	}
+	return subject, err
+}
+
+func (s *fakeAuthServer) Verify(ctx context.Context, req *pb.VerifyRequest) (*pb.VerifyResponse, error) {
+	subject, err := s.lookup(req.Token)
+	if err != nil || subject == "" {
+		return &pb.VerifyResponse{}, err
+	}
+	return &pb.VerifyResponse{
+		Valid:       true,
+		Subject:     subject,
+		Roles:       []string{"user"},
+		Permissions: []string{"tasks:read"},
+		Scopes:      []string{"tasks:read"},
+		ExpiresAt:   timestamppb.New(time.Now().Add(time.Hour)),
+	}, nil
+}
+
+func (s *fakeAuthServer) VerifySession(ctx context.Context, req *pb.VerifySessionRequest) (*pb.VerifySessionResponse, error) {
+	subject, err := s.lookup(req.SessionId)
+	if err != nil || subject == "" {
+		return &pb.VerifySessionResponse{}, err
+	}
+	return &pb.VerifySessionResponse{
+		Valid:       true,
+		Subject:     subject,
+		Roles:       []string{"user"},
+		Permissions: []string{"tasks:read"},
+		ExpiresAt:   timestamppb.New(time.Now().Add(time.Hour)),
+	}, nil
+}
+
+func (s *fakeAuthServer) WatchRevocations(req *pb.WatchRevocationsRequest, stream pb.AuthService_WatchRevocationsServer) error {
+	for {
+		select {
+		case event := <-s.events:
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// newTestClient собирает Client так же, как NewClient, но поверх bufconn
+func newTestClient(t *testing.T, srv *fakeAuthServer, opts Options) *Client {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	gs := grpc.NewServer()
+	pb.RegisterAuthServiceServer(gs, srv)
+	go gs.Serve(lis)
+	t.Cleanup(gs.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	if opts.Timeout == 0 {
+		opts.Timeout = time.Second
+	}
+	c := &Client{
+		conn:    conn,
+		client:  pb.NewAuthServiceClient(conn),
+		timeout: opts.Timeout,
+		retry:   opts.Retry,
+		breaker: newBreaker(opts.Breaker, logger),
+		cache:   newVerifyCache(opts.Cache),
+		logger:  logger,
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+// waitFor ждёт выполнения условия, которое наступает в другой горутине
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestClientVerify(t *testing.T) {
+	srv := newFakeAuthServer(map[string]string{"sess-1": "alice", "pat_abc": "bob"})
+	c := newTestClient(t, srv, Options{})
+	ctx := context.Background()
+
+	cases := []struct {
+		name        string
+		verify      func(ctx context.Context, credential string) (*Principal, error)
+		credential  string
+		wantSubject string // пусто - учётные данные недействительны
+	}{
+		{"valid session", c.VerifySession, "sess-1", "alice"},
+		{"unknown session", c.VerifySession, "sess-2", ""},
+		{"valid personal token", c.VerifyToken, "pat_abc", "bob"},
+		{"unknown personal token", c.VerifyToken, "pat_zzz", ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			principal, err := tc.verify(ctx, tc.credential)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			switch {
+			case tc.wantSubject == "" && principal != nil:
+				t.Fatalf("principal = %+v, want nil", principal)
+			case tc.wantSubject != "" && (principal == nil || principal.Subject != tc.wantSubject):
+				t.Fatalf("principal = %+v, want subject %s", principal, tc.wantSubject)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package authclient
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/shared/jwks"
+)
+
+// minForcedRefresh ограничивает частоту внеплановой загрузки JWKS при неизвестном kid
+const minForcedRefresh = 30 * time.Second
+
+var errJWKSUnavailable = errors.New("jwks unavailable")
+
+// JWKSVerifier проверяет access-токены локально по закешированному JWKS auth service,
+// без gRPC-вызова на каждый запрос
+type JWKSVerifier struct {
+	url        string
+	issuer     string
+	ttl        time.Duration
+	httpClient *http.Client
+	logger     *logrus.Logger
+
+	mu          sync.RWMutex
+	set         jwks.Set
+	fetchedAt   time.Time
+	lastRefresh time.Time
+}
+
+func NewJWKSVerifier(url, issuer string, ttl time.Duration, logger *logrus.Logger) *JWKSVerifier {
+	return &JWKSVerifier{
+		url:        url,
+		issuer:     issuer,
+		ttl:        ttl,
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+		logger:     logger,
+	}
+}
+
+// Verify проверяет подпись и claims токена. Для недействительного токена возвращает (nil, nil),
+// ошибка означает, что JWKS получить не удалось.
+func (v *JWKSVerifier) Verify(ctx context.Context, token string) (*Principal, error) {
+	claims := &jwks.Claims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.key(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(v.issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if errors.Is(err, errJWKSUnavailable) {
+		return nil, err
+	}
+	if err != nil {
+		v.logger.WithField("component", "jwks_verifier").WithError(err).Debug("token invalid")
+		return nil, nil
+	}
+
+	return &Principal{
+		Subject:     claims.Subject,
+		Roles:       claims.Roles,
+		Permissions: claims.Permissions,
+		Scopes:      claims.Scopes(),
+		ExpiresAt:   claims.ExpiresAt.Time,
+	}, nil
+}
+
+// key возвращает ключ по kid. JWKS перезагружается по истечении TTL,
+// а при неизвестном kid (ротация ключей в auth) - не чаще minForcedRefresh.
+func (v *JWKSVerifier) key(ctx context.Context, kid string) (ed25519.PublicKey, error) {
+	v.mu.RLock()
+	k, found := v.set.Lookup(kid)
+	stale := time.Since(v.fetchedAt) > v.ttl
+	canForce := time.Since(v.lastRefresh) > minForcedRefresh
+	v.mu.RUnlock()
+
+	if stale || (!found && canForce) {
+		if err := v.refresh(ctx); err != nil {
+			if !found {
+				return nil, fmt.Errorf("%w: %v", errJWKSUnavailable, err)
+			}
+			// Старый ключ ещё известен - продолжаем работать на кеше
+			v.logger.WithField("component", "jwks_verifier").WithError(err).Warn("jwks refresh failed, using cached keys")
+		}
+		v.mu.RLock()
+		k, found = v.set.Lookup(kid)
+		v.mu.RUnlock()
+	}
+
+	if !found {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return k.PublicKey()
+}
+
+func (v *JWKSVerifier) refresh(ctx context.Context) error {
+	v.mu.Lock()
+	v.lastRefresh = time.Now()
+	v.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var set jwks.Set
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("invalid jwks: %w", err)
+	}
+
+	v.mu.Lock()
+	v.set = set
+	v.fetchedAt = time.Now()
+	v.mu.Unlock()
+
+	v.logger.WithFields(logrus.Fields{
+		"component": "jwks_verifier",
+		"keys":      len(set.Keys),
+	}).Debug("jwks refreshed")
+	return nil
+}
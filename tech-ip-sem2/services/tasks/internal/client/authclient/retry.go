@@ -0,0 +1,65 @@
+package authclient
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var retriesTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "auth_client_retries_total",
+		Help: "Total number of retried auth service calls",
+	},
+	[]string{"method"},
+)
+
+// RetryConfig - повторы вызовов auth service. Повторяется только codes.Unavailable:
+// остальные ошибки либо окончательны, либо (таймаут) уже исчерпали бюджет запроса.
+type RetryConfig struct {
+	MaxAttempts int           // всего попыток, включая первую; <= 1 - без повторов
+	BaseDelay   time.Duration // задержка перед первым повтором, далее удваивается
+	MaxDelay    time.Duration // верхняя граница задержки
+}
+
+// backoff возвращает задержку перед повтором номер attempt (с 1) с полным jitter:
+// случайное значение от 0 до min(MaxDelay, BaseDelay * 2^(attempt-1))
+func (r RetryConfig) backoff(attempt int) time.Duration {
+	d := r.BaseDelay << (attempt - 1)
+	if d <= 0 || (r.MaxDelay > 0 && d > r.MaxDelay) {
+		d = r.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	return rand.N(d)
+}
+
+// withRetry выполняет fn с таймаутом c.timeout на каждую попытку и повторяет её,
+// пока auth service отвечает Unavailable и позволяет контекст запроса
+func (c *Client) withRetry(ctx context.Context, method string, fn func(ctx context.Context) error) error {
+	attempts := max(c.retry.MaxAttempts, 1)
+	for attempt := 1; ; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, c.timeout)
+		err := fn(attemptCtx)
+		cancel()
+
+		if status.Code(err) != codes.Unavailable || attempt >= attempts {
+			return err
+		}
+
+		timer := time.NewTimer(c.retry.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		retriesTotal.WithLabelValues(method).Inc()
+	}
+}
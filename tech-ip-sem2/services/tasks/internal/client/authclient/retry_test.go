@@ -0,0 +1,120 @@
+package authclient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestClientRetry(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+
+	cases := []struct {
+		name        string
+		maxAttempts int
+		errs        []error
+		wantCalls   int
+		wantErr     bool
+	}{
+		{
+			name:        "unavailable is retried until success",
+			maxAttempts: 3,
+			errs:        []error{unavailable, unavailable},
+			wantCalls:   3,
+		},
+		{
+			name:        "attempts are bounded",
+			maxAttempts: 3,
+			errs:        []error{unavailable, unavailable, unavailable, unavailable},
+			wantCalls:   3,
+			wantErr:     true,
+		},
+		{
+			name:        "no retries when max attempts is zero",
+			maxAttempts: 0,
+			errs:        []error{unavailable},
+			wantCalls:   1,
+			wantErr:     true,
+		},
+		{
+			name:        "internal error is not retried",
+			maxAttempts: 3,
+			errs:        []error{status.Error(codes.Internal, "boom")},
+			wantCalls:   1,
+			wantErr:     true,
+		},
+		{
+			name:        "deadline exceeded is not retried",
+			maxAttempts: 3,
+			errs:        []error{status.Error(codes.DeadlineExceeded, "slow")},
+			wantCalls:   1,
+			wantErr:     true,
+		},
+		{
+			name:        "unauthenticated is not retried",
+			maxAttempts: 3,
+			errs:        []error{status.Error(codes.Unauthenticated, "bad token")},
+			wantCalls:   1,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := newFakeAuthServer(map[string]string{"pat_abc": "alice"})
+			srv.failNext(tc.errs...)
+			c := newTestClient(t, srv, Options{Retry: RetryConfig{
+				MaxAttempts: tc.maxAttempts,
+				BaseDelay:   time.Millisecond,
+				MaxDelay:    5 * time.Millisecond,
+			}})
+
+			_, err := c.VerifyToken(context.Background(), "pat_abc")
+			if (err != nil) != tc.wantErr {
+				t.Errorf("error = %v, want error: %v", err, tc.wantErr)
+			}
+			if got := srv.callCount(); got != tc.wantCalls {
+				t.Errorf("auth service calls = %d, want %d", got, tc.wantCalls)
+			}
+		})
+	}
+}
+
+// Повтор прекращается, когда истекает контекст запроса
+func TestClientRetryStopsOnContextDone(t *testing.T) {
+	srv := newFakeAuthServer(nil)
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+	srv.failNext(unavailable, unavailable, unavailable, unavailable, unavailable)
+	c := newTestClient(t, srv, Options{Retry: RetryConfig{MaxAttempts: 5, BaseDelay: time.Hour, MaxDelay: time.Hour}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := c.VerifyToken(ctx, "pat_abc"); err == nil {
+		t.Fatal("expected error")
+	}
+	if got := srv.callCount(); got != 1 {
+		t.Errorf("auth service calls = %d, want 1", got)
+	}
+}
+
+func TestRetryBackoffBounds(t *testing.T) {
+	r := RetryConfig{BaseDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond}
+	cases := []struct {
+		attempt int
+		limit   time.Duration
+	}{
+		{1, 10 * time.Millisecond},
+		{2, 20 * time.Millisecond},
+		{3, 40 * time.Millisecond},
+		{4, 50 * time.Millisecond},
+		{40, 50 * time.Millisecond}, // сдвиг переполняется - берётся MaxDelay
+	}
+	for _, tc := range cases {
+		for i := 0; i < 100; i++ {
+			if d := r.backoff(tc.attempt); d < 0 || d >= tc.limit {
+				t.Fatalf("backoff(%d) = %v, want in [0, %v)", tc.attempt, d, tc.limit)
+			}
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package authclient
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/shared/tlsconfig"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// TLSConfig - параметры TLS для подключения к auth service
+type TLSConfig struct {
+	Enabled    bool
+	CAFile     string // CA bundle для проверки сервера; пустой - системные корни
+	CertFile   string // клиентский сертификат для mTLS (вместе с KeyFile)
+	KeyFile    string
+	ServerName string // имя в сертификате сервера; пустое - хост из адреса
+}
+
+// transportCredentials возвращает TLS-креды для addr или insecure, если TLS выключен
+func (c TLSConfig) transportCredentials(addr string, logger *logrus.Logger) (credentials.TransportCredentials, error) {
+	if !c.Enabled {
+		return insecure.NewCredentials(), nil
+	}
+
+	serverName := c.ServerName
+	if serverName == "" {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid auth service address %q: %w", addr, err)
+		}
+		serverName = host
+	}
+
+	reloader, err := tlsconfig.NewReloader(tlsconfig.Files{
+		CertFile: c.CertFile,
+		KeyFile:  c.KeyFile,
+		CAFile:   c.CAFile,
+	}, logger)
+	if err != nil {
+		return nil, err
+	}
+	tlsCfg, err := tlsconfig.Client(reloader, serverName)
+	if err != nil {
+		return nil, err
+	}
+	return credentials.NewTLS(tlsCfg), nil
+}
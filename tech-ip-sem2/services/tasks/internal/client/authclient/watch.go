@@ -0,0 +1,79 @@
+package authclient
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	pb "github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/proto/auth"
+)
+
+// watchBackoff - паузы между переподключениями к потоку отзывов
+var watchBackoff = RetryConfig{BaseDelay: time.Second, MaxDelay: 30 * time.Second}
+
+// WatchRevocations держит поток WatchRevocations и удаляет из кеша отозванные учётные данные.
+// Блокируется до отмены ctx; при обрыве переподключается (auth service присылает flush,
+// поэтому отзывы, пропущенные за время обрыва, не останутся в кеше).
+func (c *Client) WatchRevocations(ctx context.Context) {
+	if !c.cache.enabled() {
+		return
+	}
+	logEntry := c.logger.WithField("component", "auth_client")
+
+	for failures := 0; ; {
+		received, err := c.watchRevocations(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if received {
+			failures = 0
+		}
+		failures++
+		delay := watchBackoff.backoff(failures)
+		logEntry.WithError(err).WithField("retry_in", delay.String()).Warn("revocation stream interrupted")
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// watchRevocations читает поток до ошибки; received - было ли получено хотя бы одно событие
+func (c *Client) watchRevocations(ctx context.Context) (bool, error) {
+	stream, err := c.client.WatchRevocations(ctx, &pb.WatchRevocationsRequest{})
+	if err != nil {
+		return false, err
+	}
+
+	received := false
+	for {
+		event, err := stream.Recv()
+		if err != nil {
+			return received, err
+		}
+		if !received {
+			c.logger.WithField("component", "auth_client").Info("subscribed to auth revocations")
+		}
+		received = true
+
+		switch {
+		case event.Flush:
+			c.cache.flush()
+		case event.Subject != "":
+			c.cache.invalidateSubject(event.Subject)
+		}
+		if len(event.TokenHashes) > 0 {
+			c.cache.invalidate(event.TokenHashes)
+		}
+		c.logger.WithFields(logrus.Fields{
+			"component": "auth_client",
+			"subject":   event.Subject,
+			"tokens":    len(event.TokenHashes),
+			"flush":     event.Flush,
+		}).Debug("revocation received")
+	}
+}
@@ -0,0 +1,88 @@
+package authclient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/proto/auth"
+)
+
+func TestWatchRevocations(t *testing.T) {
+	cases := []struct {
+		name       string
+		event      *pb.RevocationEvent
+		wantCached map[string]bool // session_id -> остаётся ли в кеше
+	}{
+		{
+			name:       "token hash removes one credential",
+			event:      &pb.RevocationEvent{TokenHashes: []string{credentialHash("sess-a1")}},
+			wantCached: map[string]bool{"sess-a1": false, "sess-a2": true, "sess-b1": true},
+		},
+		{
+			name:       "subject removes all its credentials",
+			event:      &pb.RevocationEvent{Subject: "alice"},
+			wantCached: map[string]bool{"sess-a1": false, "sess-a2": false, "sess-b1": true},
+		},
+		{
+			name:       "flush removes everything",
+			event:      &pb.RevocationEvent{Flush: true},
+			wantCached: map[string]bool{"sess-a1": false, "sess-a2": false, "sess-b1": false},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := newFakeAuthServer(map[string]string{"sess-a1": "alice", "sess-a2": "alice", "sess-b1": "bob"})
+			c := newTestClient(t, srv, Options{Cache: CacheConfig{Size: 10, TTL: time.Minute}})
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go c.WatchRevocations(ctx)
+
+			for session := range tc.wantCached {
+				if _, err := c.VerifySession(ctx, session); err != nil {
+					t.Fatal(err)
+				}
+			}
+			gen := c.cache.generation()
+			srv.events <- tc.event
+			waitFor(t, "revocation to be applied", func() bool { return c.cache.generation() > gen })
+
+			for session, want := range tc.wantCached {
+				c.cache.mu.Lock()
+				_, cached := c.cache.items[credentialHash(session)]
+				c.cache.mu.Unlock()
+				if cached != want {
+					t.Errorf("%s cached = %v, want %v", session, cached, want)
+				}
+			}
+		})
+	}
+}
+
+// Отзыв, пришедший по потоку во время проверки, не даёт закешировать её результат
+func TestWatchRevocationsDuringVerify(t *testing.T) {
+	srv := newFakeAuthServer(map[string]string{"sess-1": "alice"})
+	c := newTestClient(t, srv, Options{Cache: CacheConfig{Size: 10, TTL: time.Minute}})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.WatchRevocations(ctx)
+
+	srv.onVerify = func() {
+		gen := c.cache.generation()
+		srv.events <- &pb.RevocationEvent{Subject: "alice"}
+		for c.cache.generation() == gen { // горутина сервера: t.Fatal здесь недопустим
+			time.Sleep(time.Millisecond)
+		}
+	}
+	if _, err := c.VerifySession(ctx, "sess-1"); err != nil {
+		t.Fatal(err)
+	}
+	srv.mu.Lock()
+	srv.onVerify = nil
+	srv.mu.Unlock()
+
+	c.VerifySession(ctx, "sess-1")
+	if got := srv.callCount(); got != 2 {
+		t.Fatalf("auth service calls = %d, want 2: result verified before revocation was cached", got)
+	}
+}
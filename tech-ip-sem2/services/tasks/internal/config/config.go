@@ -3,6 +3,9 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type DatabaseConfig struct {
@@ -14,18 +17,82 @@ type DatabaseConfig struct {
 	Driver   string // "postgres" или "sqlite3"
 }
 
+// AuthGRPCTLSConfig - TLS для подключения к auth service по gRPC
+type AuthGRPCTLSConfig struct {
+	Enabled    bool
+	CAFile     string // CA bundle сервера; пустой - системные корни
+	CertFile   string // клиентский сертификат для mTLS
+	KeyFile    string
+	ServerName string // имя в сертификате сервера, если отличается от хоста в AUTH_GRPC_ADDR
+}
+
+// AuthResilienceConfig - таймауты, повторы и circuit breaker для вызовов auth service
+type AuthResilienceConfig struct {
+	Timeout          time.Duration // таймаут одной попытки
+	MaxAttempts      int           // попыток на вызов, включая первую (повторяется только Unavailable)
+	RetryBaseDelay   time.Duration
+	RetryMaxDelay    time.Duration
+	FailureThreshold int           // неудач подряд до размыкания; 0 - breaker выключен
+	OpenTimeout      time.Duration // пауза до пробного вызова после размыкания
+	// FailOpenRoutes - маршруты ("GET /healthz"), которые при недоступности auth service
+	// обслуживаются без проверки пользователя; остальные отвечают 503 (fail-closed)
+	FailOpenRoutes []string
+}
+
+// FailClosedRoutes - маршруты, которые нельзя сделать fail-open. Задачи пользователя привязаны
+// к владельцу, которого без auth service не определить, а админские операции требуют проверки роли.
+var FailClosedRoutes = map[string]bool{
+	"POST /v1/tasks":              true,
+	"GET /v1/tasks":               true,
+	"GET /v1/tasks/{id}":          true,
+	"PATCH /v1/tasks/{id}":        true,
+	"DELETE /v1/tasks/{id}":       true,
+	"GET /v1/tasks/search":        true,
+	"GET /v1/admin/tasks":         true,
+	"DELETE /v1/admin/tasks/{id}": true,
+}
+
+// AuthCacheConfig - кеш результатов проверки сессий и токенов
+type AuthCacheConfig struct {
+	Size        int           // максимум записей; 0 - кеш выключен
+	TTL         time.Duration // срок действительных результатов
+	NegativeTTL time.Duration // срок результатов "недействителен"
+}
+
 type Config struct {
-	TasksPort    string
-	AuthGRPCAddr string
-	LogLevel     string
-	DB           DatabaseConfig
+	TasksPort       string
+	ShutdownTimeout time.Duration // сколько ждать завершения текущих запросов при остановке
+	// DeletedRetention - сколько хранятся задачи, удалённые пользователем (deleted_at),
+	// до окончательного удаления; 0 - не удалять
+	DeletedRetention time.Duration
+	AuthGRPCAddr     string
+	AuthGRPCTLS      AuthGRPCTLSConfig
+	AuthResilience   AuthResilienceConfig
+	AuthCache        AuthCacheConfig
+	LogLevel         string
+	CSRFSecret       string // общий с auth service секрет для HMAC CSRF-токенов
+	AuthJWKSURL      string // если задан, access-токены проверяются локально по JWKS
+	AuthIssuer       string
+	DB               DatabaseConfig
 }
 
+// DefaultCSRFSecret используется, если CSRF_SECRET не задан (только для разработки)
+const DefaultCSRFSecret = "dev-csrf-secret-change-me"
+
 func Load() (*Config, error) {
 	cfg := &Config{
 		TasksPort:    getEnv("TASKS_PORT", "8082"),
 		AuthGRPCAddr: getEnv("AUTH_GRPC_ADDR", "localhost:50051"),
 		LogLevel:     getEnv("LOG_LEVEL", "info"),
+		CSRFSecret:   getEnv("CSRF_SECRET", DefaultCSRFSecret),
+		AuthJWKSURL:  getEnv("AUTH_JWKS_URL", ""),
+		AuthIssuer:   getEnv("AUTH_JWT_ISSUER", "auth-service"),
+		AuthGRPCTLS: AuthGRPCTLSConfig{
+			CAFile:     getEnv("AUTH_GRPC_CA_FILE", ""),
+			CertFile:   getEnv("AUTH_GRPC_CLIENT_CERT", ""),
+			KeyFile:    getEnv("AUTH_GRPC_CLIENT_KEY", ""),
+			ServerName: getEnv("AUTH_GRPC_SERVER_NAME", ""),
+		},
 		DB: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
 			Port:     getEnv("DB_PORT", "5432"),
@@ -35,6 +102,75 @@ func Load() (*Config, error) {
 			Driver:   getEnv("DB_DRIVER", "postgres"),
 		},
 	}
+
+	// TLS включается явно или автоматически, если задан CA bundle или клиентский сертификат
+	tlsEnabled, err := strconv.ParseBool(getEnv("AUTH_GRPC_TLS", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid AUTH_GRPC_TLS: %w", err)
+	}
+	t := &cfg.AuthGRPCTLS
+	t.Enabled = tlsEnabled || t.CAFile != "" || t.CertFile != ""
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return nil, fmt.Errorf("AUTH_GRPC_CLIENT_CERT and AUTH_GRPC_CLIENT_KEY must be set together")
+	}
+
+	if cfg.ShutdownTimeout, err = getDuration("TASKS_SHUTDOWN_TIMEOUT", 15*time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.DeletedRetention, err = getDuration("TASKS_DELETED_RETENTION", 30*24*time.Hour); err != nil {
+		return nil, err
+	}
+	if cfg.DeletedRetention < 0 {
+		return nil, fmt.Errorf("TASKS_DELETED_RETENTION must not be negative")
+	}
+
+	a := &cfg.AuthResilience
+	if a.Timeout, err = getDuration("AUTH_GRPC_TIMEOUT", 2*time.Second); err != nil {
+		return nil, err
+	}
+	if a.MaxAttempts, err = getInt("AUTH_RETRY_MAX_ATTEMPTS", 3); err != nil {
+		return nil, err
+	}
+	if a.RetryBaseDelay, err = getDuration("AUTH_RETRY_BASE_DELAY", 100*time.Millisecond); err != nil {
+		return nil, err
+	}
+	if a.RetryMaxDelay, err = getDuration("AUTH_RETRY_MAX_DELAY", time.Second); err != nil {
+		return nil, err
+	}
+	if a.FailureThreshold, err = getInt("AUTH_BREAKER_FAILURE_THRESHOLD", 5); err != nil {
+		return nil, err
+	}
+	if a.OpenTimeout, err = getDuration("AUTH_BREAKER_OPEN_TIMEOUT", 10*time.Second); err != nil {
+		return nil, err
+	}
+	if a.Timeout <= 0 {
+		return nil, fmt.Errorf("AUTH_GRPC_TIMEOUT must be positive")
+	}
+	if a.MaxAttempts < 1 {
+		return nil, fmt.Errorf("AUTH_RETRY_MAX_ATTEMPTS must be at least 1")
+	}
+
+	c := &cfg.AuthCache
+	if c.Size, err = getInt("AUTH_CACHE_SIZE", 10000); err != nil {
+		return nil, err
+	}
+	if c.TTL, err = getDuration("AUTH_CACHE_TTL", 30*time.Second); err != nil {
+		return nil, err
+	}
+	if c.NegativeTTL, err = getDuration("AUTH_CACHE_NEGATIVE_TTL", 5*time.Second); err != nil {
+		return nil, err
+	}
+	if c.Size < 0 {
+		return nil, fmt.Errorf("AUTH_CACHE_SIZE must not be negative")
+	}
+	for _, route := range strings.Split(getEnv("AUTH_FAIL_OPEN_ROUTES", ""), ",") {
+		if route = strings.Join(strings.Fields(route), " "); route != "" {
+			if FailClosedRoutes[route] {
+				return nil, fmt.Errorf("AUTH_FAIL_OPEN_ROUTES: route %q is owner-scoped or admin-only and must fail closed", route)
+			}
+			a.FailOpenRoutes = append(a.FailOpenRoutes, route)
+		}
+	}
 	return cfg, nil
 }
 
@@ -45,6 +181,30 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+func getDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return d, nil
+}
+
+func getInt(key string, defaultValue int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return n, nil
+}
+
 func (db *DatabaseConfig) DSN() string {
 	switch db.Driver {
 	case "postgres":
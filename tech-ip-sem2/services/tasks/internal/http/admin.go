@@ -0,0 +1,79 @@
+package http
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/shared/middleware"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/tasks/internal/models"
+)
+
+// AdminListTasks обрабатывает GET /v1/admin/tasks: все задачи, включая удалённые
+func (h *TaskHandler) AdminListTasks(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r.Context())
+	logEntry := h.logger.WithFields(logrus.Fields{
+		"component":  "http_handler",
+		"handler":    "AdminListTasks",
+		"request_id": requestID,
+	})
+
+	principal, ok := h.authorize(w, r, permTasksReadAll)
+	if !ok {
+		return
+	}
+	logEntry = logEntry.WithField("subject", principal.Subject)
+
+	tasks, err := h.taskService.ListAll(r.Context())
+	if err != nil {
+		logEntry.WithError(err).Error("failed to list all tasks")
+		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	logEntry.WithField("count", len(tasks)).Info("all tasks listed by admin")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toAdminTaskResponses(tasks))
+}
+
+// toAdminTaskResponses дополняет ответ владельцем задачи
+func toAdminTaskResponses(tasks []*models.Task) []taskResponse {
+	result := toTaskResponses(tasks)
+	for i, t := range tasks {
+		result[i].Owner = t.OwnerID
+	}
+	return result
+}
+
+// AdminDeleteTask обрабатывает DELETE /v1/admin/tasks/{id}: безвозвратное удаление
+func (h *TaskHandler) AdminDeleteTask(w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetRequestID(r.Context())
+	logEntry := h.logger.WithFields(logrus.Fields{
+		"component":  "http_handler",
+		"handler":    "AdminDeleteTask",
+		"request_id": requestID,
+	})
+
+	principal, ok := h.authorize(w, r, permTasksHardDelete)
+	if !ok {
+		return
+	}
+	logEntry = logEntry.WithField("subject", principal.Subject)
+
+	id := r.PathValue("id")
+	err := h.taskService.HardDelete(r.Context(), id)
+	if err == sql.ErrNoRows {
+		logEntry.WithField("task_id", id).Warn("task not found for hard deletion")
+		http.Error(w, `{"error":"task not found"}`, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logEntry.WithError(err).Error("failed to hard delete task")
+		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	logEntry.WithField("task_id", id).Info("task permanently deleted by admin")
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,48 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/tasks/internal/client/authclient"
+)
+
+// FailPolicy - поведение маршрута, когда auth service недоступен
+type FailPolicy int
+
+const (
+	// FailClosed - запрос отклоняется с 503 (по умолчанию)
+	FailClosed FailPolicy = iota
+	// FailOpen - запрос обслуживается без проверки пользователя, с анонимным Principal,
+	// которому разрешено только чтение. Config не допускает его для маршрутов из FailClosedRoutes;
+	// задачи пользователя привязаны к владельцу, поэтому они и так отвечают 503.
+	FailOpen
+)
+
+type failPolicyKey struct{}
+
+// WithFailPolicy задаёт политику для обработчика маршрута
+func WithFailPolicy(policy FailPolicy, next http.Handler) http.Handler {
+	if policy == FailClosed {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), failPolicyKey{}, policy)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func failPolicy(ctx context.Context) FailPolicy {
+	policy, _ := ctx.Value(failPolicyKey{}).(FailPolicy)
+	return policy
+}
+
+// unverifiedPrincipal - пользователь fail-open маршрута, которого не удалось проверить.
+// Ему доступно только чтение; админские и изменяющие операции отвечают 503.
+func unverifiedPrincipal() *authclient.Principal {
+	return &authclient.Principal{
+		Permissions: []string{permTasksRead},
+		Scopes:      []string{scopeTasksRead},
+		Unverified:  true,
+	}
+}
@@ -3,12 +3,15 @@ package http
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/shared/middleware"
 	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/tasks/internal/client/authclient"
+	customMiddleware "github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/tasks/internal/middleware"
 	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/tasks/internal/models"
 	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/tasks/internal/service"
 )
@@ -27,8 +30,121 @@ func NewTaskHandler(ts *service.TaskService, ac *authclient.Client, logger *logr
 	}
 }
 
-// verifySession проверяет сессию через cookie (вместо Bearer token)
-func (h *TaskHandler) verifySession(w http.ResponseWriter, r *http.Request) bool {
+// Разрешения Bearer-токенов, выдаваемые auth service
+const (
+	scopeTasksRead  = "tasks:read"
+	scopeTasksWrite = "tasks:write"
+)
+
+// Разрешения пользователя, которые auth service выводит из его ролей
+const (
+	permTasksRead       = "tasks:read"
+	permTasksWrite      = "tasks:write"
+	permTasksReadAll    = "tasks:read_all"    // задачи всех пользователей (админ)
+	permTasksHardDelete = "tasks:hard_delete" // безвозвратное удаление (админ)
+)
+
+// permissionScopes - какой scope нужен Bearer-токену для операции с данным разрешением
+var permissionScopes = map[string]string{
+	permTasksRead:       scopeTasksRead,
+	permTasksWrite:      scopeTasksWrite,
+	permTasksReadAll:    scopeTasksRead,
+	permTasksHardDelete: scopeTasksWrite,
+}
+
+// authorize аутентифицирует запрос и проверяет, что роли пользователя дают разрешение.
+// Bearer-токену дополнительно нужен соответствующий scope. Непроверенному пользователю
+// fail-open маршрута (см. FailPolicy) разрешено только чтение, иначе ответ 503.
+func (h *TaskHandler) authorize(w http.ResponseWriter, r *http.Request, permission string) (*authclient.Principal, bool) {
+	principal, ok := h.authenticate(w, r, permissionScopes[permission])
+	if !ok {
+		return nil, false
+	}
+	if !principal.HasPermission(permission) {
+		if principal.Unverified {
+			h.logger.WithFields(logrus.Fields{
+				"component":  "http_handler",
+				"request_id": middleware.GetRequestID(r.Context()),
+				"permission": permission,
+			}).Warn("permission cannot be checked while auth service is unavailable")
+			http.Error(w, `{"error":"auth service unavailable"}`, http.StatusServiceUnavailable)
+			return nil, false
+		}
+		h.logger.WithFields(logrus.Fields{
+			"component":  "http_handler",
+			"request_id": middleware.GetRequestID(r.Context()),
+			"subject":    principal.Subject,
+			"permission": permission,
+		}).Warn("permission denied")
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return nil, false
+	}
+	return principal, true
+}
+
+// authorizeOwner - authorize для операций с задачами пользователя. Они ограничены владельцем,
+// поэтому непроверенного пользователя fail-open маршрута обслужить нельзя - ответ 503.
+func (h *TaskHandler) authorizeOwner(w http.ResponseWriter, r *http.Request, permission string) (*authclient.Principal, bool) {
+	principal, ok := h.authorize(w, r, permission)
+	if !ok {
+		return nil, false
+	}
+	if principal.Unverified {
+		h.logger.WithFields(logrus.Fields{
+			"component":  "http_handler",
+			"request_id": middleware.GetRequestID(r.Context()),
+		}).Warn("task owner unknown while auth service is unavailable")
+		http.Error(w, `{"error":"auth service unavailable"}`, http.StatusServiceUnavailable)
+		return nil, false
+	}
+	return principal, true
+}
+
+// authenticate определяет пользователя: по Authorization: Bearer (скрипты, персональные токены)
+// или по session cookie (браузер). При наличии Bearer cookie не учитывается.
+// Для Bearer-токена дополнительно проверяется разрешение scope.
+func (h *TaskHandler) authenticate(w http.ResponseWriter, r *http.Request, scope string) (*authclient.Principal, bool) {
+	if token, ok := customMiddleware.BearerToken(r); ok {
+		return h.verifyBearer(w, r, token, scope)
+	}
+	return h.verifySession(w, r)
+}
+
+// verifyBearer проверяет Bearer-токен через auth service и его разрешения
+func (h *TaskHandler) verifyBearer(w http.ResponseWriter, r *http.Request, token, scope string) (*authclient.Principal, bool) {
+	requestID := middleware.GetRequestID(r.Context())
+	logEntry := h.logger.WithFields(logrus.Fields{
+		"component":  "http_handler",
+		"request_id": requestID,
+	})
+
+	principal, err := h.authClient.VerifyToken(r.Context(), token)
+	if err != nil {
+		return h.authUnavailable(w, r, logEntry, err)
+	}
+	if principal == nil {
+		logEntry.Warn("invalid bearer token")
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		http.Error(w, `{"error":"unauthorized - invalid token"}`, http.StatusUnauthorized)
+		return nil, false
+	}
+	if !principal.HasScope(scope) {
+		logEntry.WithFields(logrus.Fields{
+			"subject": principal.Subject,
+			"scope":   scope,
+		}).Warn("insufficient token scope")
+		w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+scope+`"`)
+		http.Error(w, `{"error":"forbidden - token lacks scope `+scope+`"}`, http.StatusForbidden)
+		return nil, false
+	}
+
+	logEntry.WithField("subject", principal.Subject).Debug("bearer token verified successfully")
+	return principal, true
+}
+
+// verifySession проверяет сессию через cookie.
+// Сессии хранятся в auth service, поэтому проверка идёт через gRPC VerifySession.
+func (h *TaskHandler) verifySession(w http.ResponseWriter, r *http.Request) (*authclient.Principal, bool) {
 	requestID := middleware.GetRequestID(r.Context())
 	logEntry := h.logger.WithFields(logrus.Fields{
 		"component":  "http_handler",
@@ -40,19 +156,33 @@ func (h *TaskHandler) verifySession(w http.ResponseWriter, r *http.Request) bool
 	if err != nil {
 		logEntry.Warn("session cookie missing")
 		http.Error(w, `{"error":"unauthorized - session cookie missing"}`, http.StatusUnauthorized)
-		return false
+		return nil, false
 	}
 
-	// В учебном проекте просто проверяем, что cookie не пустая и равна ожидаемой
-	// В реальном проекте здесь была бы проверка в БД или Redis
-	if sessionCookie.Value != "demo-session-123" {
+	principal, err := h.authClient.VerifySession(r.Context(), sessionCookie.Value)
+	if err != nil {
+		return h.authUnavailable(w, r, logEntry, err)
+	}
+	if principal == nil {
 		logEntry.Warn("invalid session")
 		http.Error(w, `{"error":"unauthorized - invalid session"}`, http.StatusUnauthorized)
-		return false
+		return nil, false
 	}
 
-	logEntry.Debug("session verified successfully")
-	return true
+	logEntry.WithField("subject", principal.Subject).Debug("session verified successfully")
+	return principal, true
+}
+
+// authUnavailable применяет политику маршрута, когда auth service не смог проверить пользователя:
+// fail-open пропускает запрос с непроверенным Principal, fail-closed отвечает 503
+func (h *TaskHandler) authUnavailable(w http.ResponseWriter, r *http.Request, logEntry *logrus.Entry, err error) (*authclient.Principal, bool) {
+	if failPolicy(r.Context()) == FailOpen {
+		logEntry.WithError(err).Warn("auth service unavailable, serving fail-open route without verification")
+		return unverifiedPrincipal(), true
+	}
+	logEntry.WithError(err).Error("auth verification failed")
+	http.Error(w, `{"error":"auth service unavailable"}`, http.StatusServiceUnavailable)
+	return nil, false
 }
 
 // sanitizeInput - простая защита от XSS (замена опасных символов)
@@ -82,11 +212,13 @@ type updateTaskRequest struct {
 }
 
 type taskResponse struct {
-	ID          string `json:"id"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	DueDate     string `json:"due_date,omitempty"`
-	Done        bool   `json:"done"`
+	ID          string     `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	DueDate     *time.Time `json:"due_date,omitempty"`
+	Done        bool       `json:"done"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"` // только в ответах для администраторов
+	Owner       string     `json:"owner,omitempty"`      // только в ответах для администраторов
 }
 
 func toTaskResponse(t *models.Task) taskResponse {
@@ -96,6 +228,7 @@ func toTaskResponse(t *models.Task) taskResponse {
 		Description: t.Description,
 		DueDate:     t.DueDate,
 		Done:        t.Done,
+		DeletedAt:   t.DeletedAt,
 	}
 }
 
@@ -116,9 +249,11 @@ func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 		"request_id": requestID,
 	})
 
-	if !h.verifySession(w, r) {
+	principal, ok := h.authorizeOwner(w, r, permTasksWrite)
+	if !ok {
 		return
 	}
+	logEntry = logEntry.WithField("subject", principal.Subject)
 
 	var req createTaskRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -136,7 +271,12 @@ func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	task, err := h.taskService.Create(r.Context(), req.Title, req.Description, req.DueDate)
+	task, err := h.taskService.Create(r.Context(), principal.Subject, req.Title, req.Description, req.DueDate)
+	if errors.Is(err, service.ErrInvalidDueDate) {
+		logEntry.WithField("due_date", req.DueDate).Warn("invalid due date")
+		http.Error(w, `{"error":"invalid due_date: expected RFC 3339 (2025-05-01T18:00:00Z) or YYYY-MM-DD"}`, http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		logEntry.WithError(err).Error("failed to create task")
 		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
@@ -158,11 +298,13 @@ func (h *TaskHandler) ListTasks(w http.ResponseWriter, r *http.Request) {
 		"request_id": requestID,
 	})
 
-	if !h.verifySession(w, r) {
+	principal, ok := h.authorizeOwner(w, r, permTasksRead)
+	if !ok {
 		return
 	}
+	logEntry = logEntry.WithField("subject", principal.Subject)
 
-	tasks, err := h.taskService.List(r.Context())
+	tasks, err := h.taskService.List(r.Context(), principal.Subject)
 	if err != nil {
 		logEntry.WithError(err).Error("failed to list tasks")
 		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
@@ -183,12 +325,14 @@ func (h *TaskHandler) GetTask(w http.ResponseWriter, r *http.Request) {
 		"request_id": requestID,
 	})
 
-	if !h.verifySession(w, r) {
+	principal, ok := h.authorizeOwner(w, r, permTasksRead)
+	if !ok {
 		return
 	}
+	logEntry = logEntry.WithField("subject", principal.Subject)
 
 	id := r.PathValue("id")
-	task, err := h.taskService.GetByID(r.Context(), id)
+	task, err := h.taskService.GetByID(r.Context(), principal.Subject, id)
 	if err == sql.ErrNoRows {
 		logEntry.WithField("task_id", id).Warn("task not found")
 		http.Error(w, `{"error":"task not found"}`, http.StatusNotFound)
@@ -214,9 +358,11 @@ func (h *TaskHandler) UpdateTask(w http.ResponseWriter, r *http.Request) {
 		"request_id": requestID,
 	})
 
-	if !h.verifySession(w, r) {
+	principal, ok := h.authorizeOwner(w, r, permTasksWrite)
+	if !ok {
 		return
 	}
+	logEntry = logEntry.WithField("subject", principal.Subject)
 
 	id := r.PathValue("id")
 	var req updateTaskRequest
@@ -233,7 +379,12 @@ func (h *TaskHandler) UpdateTask(w http.ResponseWriter, r *http.Request) {
 	}
 	// title тоже можно санитизировать, но для учебных целей оставим как есть
 
-	task, err := h.taskService.Update(r.Context(), id, req.Title, req.Description, req.DueDate, req.Done)
+	task, err := h.taskService.Update(r.Context(), principal.Subject, id, req.Title, req.Description, req.DueDate, req.Done)
+	if errors.Is(err, service.ErrInvalidDueDate) {
+		logEntry.WithField("due_date", *req.DueDate).Warn("invalid due date")
+		http.Error(w, `{"error":"invalid due_date: expected RFC 3339 (2025-05-01T18:00:00Z) or YYYY-MM-DD"}`, http.StatusBadRequest)
+		return
+	}
 	if err == sql.ErrNoRows {
 		logEntry.WithField("task_id", id).Warn("task not found for update")
 		http.Error(w, `{"error":"task not found"}`, http.StatusNotFound)
@@ -259,12 +410,14 @@ func (h *TaskHandler) DeleteTask(w http.ResponseWriter, r *http.Request) {
 		"request_id": requestID,
 	})
 
-	if !h.verifySession(w, r) {
+	principal, ok := h.authorizeOwner(w, r, permTasksWrite)
+	if !ok {
 		return
 	}
+	logEntry = logEntry.WithField("subject", principal.Subject)
 
 	id := r.PathValue("id")
-	err := h.taskService.Delete(r.Context(), id)
+	err := h.taskService.Delete(r.Context(), principal.Subject, id)
 	if err == sql.ErrNoRows {
 		logEntry.WithField("task_id", id).Warn("task not found for deletion")
 		http.Error(w, `{"error":"task not found"}`, http.StatusNotFound)
@@ -289,9 +442,11 @@ func (h *TaskHandler) SearchTasks(w http.ResponseWriter, r *http.Request) {
 		"request_id": requestID,
 	})
 
-	if !h.verifySession(w, r) {
+	principal, ok := h.authorizeOwner(w, r, permTasksRead)
+	if !ok {
 		return
 	}
+	logEntry = logEntry.WithField("subject", principal.Subject)
 
 	query := r.URL.Query().Get("q")
 	if query == "" {
@@ -309,7 +464,7 @@ func (h *TaskHandler) SearchTasks(w http.ResponseWriter, r *http.Request) {
 		"unsafe": unsafe,
 	}).Info("searching tasks")
 
-	tasks, err := h.taskService.SearchByTitle(r.Context(), query, unsafe)
+	tasks, err := h.taskService.SearchByTitle(r.Context(), principal.Subject, query, unsafe)
 	if err != nil {
 		logEntry.WithError(err).Error("failed to search tasks")
 		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
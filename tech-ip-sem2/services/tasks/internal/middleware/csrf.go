@@ -2,36 +2,74 @@ package middleware
 
 import (
 	"net/http"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/shared/csrf"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/shared/middleware"
 )
 
-// CSRFMiddleware проверяет CSRF-токен для state-changing методов
-func CSRFMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Только для POST, PATCH, DELETE
-		if r.Method == http.MethodPost || r.Method == http.MethodPatch || r.Method == http.MethodDelete {
-			// Получаем CSRF токен из cookie
-			csrfCookie, err := r.Cookie("csrf_token")
-			if err != nil {
-				http.Error(w, `{"error":"CSRF token missing in cookies"}`, http.StatusForbidden)
-				return
-			}
+// CSRFMiddleware проверяет CSRF-токен для state-changing методов.
+// Токен из заголовка X-CSRF-Token должен совпадать с HMAC от session cookie,
+// поэтому подложенная злоумышленником cookie csrf_token не помогает.
+// Запросы с Authorization: Bearer не проверяются: браузер не подставляет этот
+// заголовок сам, а хендлеры при его наличии игнорируют cookie.
+func CSRFMiddleware(secret []byte, logger *logrus.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Только для POST, PATCH, DELETE и только для cookie-аутентификации
+			stateChanging := r.Method == http.MethodPost || r.Method == http.MethodPatch || r.Method == http.MethodDelete
+			if stateChanging && !HasBearerToken(r) {
+				reject := func(reason, body string) {
+					logger.WithFields(logrus.Fields{
+						"component":  "csrf_middleware",
+						"request_id": middleware.GetRequestID(r.Context()),
+						"method":     r.Method,
+						"path":       r.URL.Path,
+						"reason":     reason,
+					}).Warn("CSRF check failed")
+					http.Error(w, body, http.StatusForbidden)
+				}
 
-			// Получаем CSRF токен из заголовка
-			csrfHeader := r.Header.Get("X-CSRF-Token")
-			if csrfHeader == "" {
-				http.Error(w, `{"error":"X-CSRF-Token header missing"}`, http.StatusForbidden)
-				return
-			}
+				// Токен привязан к сессии
+				sessionCookie, err := r.Cookie("session_id")
+				if err != nil {
+					reject("session_cookie_missing", `{"error":"CSRF check failed - session cookie missing"}`)
+					return
+				}
+
+				// Получаем CSRF токен из заголовка
+				csrfHeader := r.Header.Get("X-CSRF-Token")
+				if csrfHeader == "" {
+					reject("header_missing", `{"error":"X-CSRF-Token header missing"}`)
+					return
+				}
 
-			// Сравниваем
-			if csrfCookie.Value != csrfHeader {
-				// Можно добавить логирование попытки
-				http.Error(w, `{"error":"CSRF token mismatch"}`, http.StatusForbidden)
-				return
+				// Сравниваем с HMAC от сессии (constant-time)
+				if !csrf.Valid(secret, sessionCookie.Value, csrfHeader) {
+					reject("token_mismatch", `{"error":"CSRF token mismatch"}`)
+					return
+				}
 			}
-		}
 
-		// Если проверка пройдена или метод безопасный, передаём дальше
-		next.ServeHTTP(w, r)
-	})
+			// Если проверка пройдена или метод безопасный, передаём дальше
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// HasBearerToken сообщает, передан ли в запросе заголовок Authorization: Bearer
+func HasBearerToken(r *http.Request) bool {
+	_, ok := BearerToken(r)
+	return ok
+}
+
+// BearerToken извлекает токен из заголовка Authorization: Bearer <token>
+func BearerToken(r *http.Request) (string, bool) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
 }
@@ -3,11 +3,13 @@ package models
 import "time"
 
 type Task struct {
-	ID          string    `json:"id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	DueDate     string    `json:"due_date,omitempty"`
-	Done        bool      `json:"done"`
-	CreatedAt   time.Time `json:"-"`
-	UpdatedAt   time.Time `json:"-"`
+	ID          string     `json:"id"`
+	OwnerID     string     `json:"-"` // subject пользователя, создавшего задачу
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	DueDate     *time.Time `json:"due_date,omitempty"` // nil - срок не задан
+	Done        bool       `json:"done"`
+	CreatedAt   time.Time  `json:"-"`
+	UpdatedAt   time.Time  `json:"-"`
+	DeletedAt   *time.Time `json:"-"` // задача удалена пользователем (мягкое удаление)
 }
@@ -2,14 +2,28 @@ package repository
 
 import (
 	"context"
+	"time"
+
 	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/tasks/internal/models"
 )
 
+// TaskRepository - хранилище задач. Методы пользователя ограничены задачами владельца (ownerID):
+// чужая задача неотличима от несуществующей. GetByID возвращает (nil, nil), если задача не найдена.
 type TaskRepository interface {
 	Create(ctx context.Context, task *models.Task) error
-	GetByID(ctx context.Context, id string) (*models.Task, error)
-	List(ctx context.Context) ([]*models.Task, error)
+	GetByID(ctx context.Context, ownerID, id string) (*models.Task, error)
+	List(ctx context.Context, ownerID string) ([]*models.Task, error)
+	// Update сохраняет задачу, если она принадлежит task.OwnerID
 	Update(ctx context.Context, task *models.Task) error
-	Delete(ctx context.Context, id string) error
-	SearchByTitle(ctx context.Context, titleSubstring string) ([]*models.Task, error)
+	// Delete помечает задачу удалённой; удалённые задачи не видны в остальных методах
+	// и окончательно удаляются PurgeDeleted по истечении срока хранения
+	Delete(ctx context.Context, ownerID, id string) error
+	SearchByTitle(ctx context.Context, ownerID, titleSubstring string) ([]*models.Task, error)
+
+	// Для администраторов: задачи всех пользователей
+	ListAll(ctx context.Context) ([]*models.Task, error) // включая удалённые
+	HardDelete(ctx context.Context, id string) error     // безвозвратно, в том числе удалённые
+
+	// PurgeDeleted безвозвратно удаляет задачи, помеченные удалёнными раньше before
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
 }
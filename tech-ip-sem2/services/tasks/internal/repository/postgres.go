@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	_ "github.com/lib/pq"
 	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/tasks/internal/models"
@@ -28,40 +29,41 @@ func (r *PostgresTaskRepository) Close() error {
 	return r.db.Close()
 }
 
-func (r *PostgresTaskRepository) Create(ctx context.Context, task *models.Task) error {
-	query := `INSERT INTO tasks (id, title, description, done, created_at, updated_at) 
-              VALUES ($1, $2, $3, $4, $5, $6)`
-	_, err := r.db.ExecContext(ctx, query,
-		task.ID, task.Title, task.Description, task.Done, task.CreatedAt, task.UpdatedAt)
-	return err
+// Ping проверяет соединение с базой (для /readyz)
+func (r *PostgresTaskRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
+// taskColumns - порядок колонок, который ожидает scanTask
+const taskColumns = `id, owner_id, title, description, due_date, done, created_at, updated_at, deleted_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
 }
 
-func (r *PostgresTaskRepository) GetByID(ctx context.Context, id string) (*models.Task, error) {
-	query := `SELECT id, title, description, done, created_at, updated_at FROM tasks WHERE id = $1`
+func scanTask(row rowScanner) (*models.Task, error) {
 	task := &models.Task{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&task.ID, &task.Title, &task.Description, &task.Done, &task.CreatedAt, &task.UpdatedAt)
-	if err == sql.ErrNoRows {
-		return nil, nil
-	}
+	var dueDate, deletedAt sql.NullTime
+	err := row.Scan(&task.ID, &task.OwnerID, &task.Title, &task.Description, &dueDate, &task.Done,
+		&task.CreatedAt, &task.UpdatedAt, &deletedAt)
 	if err != nil {
 		return nil, err
 	}
+	if dueDate.Valid {
+		task.DueDate = &dueDate.Time
+	}
+	if deletedAt.Valid {
+		task.DeletedAt = &deletedAt.Time
+	}
 	return task, nil
 }
 
-func (r *PostgresTaskRepository) List(ctx context.Context) ([]*models.Task, error) {
-	query := `SELECT id, title, description, done, created_at, updated_at FROM tasks ORDER BY created_at DESC`
-	rows, err := r.db.QueryContext(ctx, query)
-	if err != nil {
-		return nil, err
-	}
+func scanTasks(rows *sql.Rows) ([]*models.Task, error) {
 	defer rows.Close()
 
 	var tasks []*models.Task
 	for rows.Next() {
-		task := &models.Task{}
-		err := rows.Scan(&task.ID, &task.Title, &task.Description, &task.Done, &task.CreatedAt, &task.UpdatedAt)
+		task, err := scanTask(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -70,9 +72,42 @@ func (r *PostgresTaskRepository) List(ctx context.Context) ([]*models.Task, erro
 	return tasks, rows.Err()
 }
 
+func (r *PostgresTaskRepository) Create(ctx context.Context, task *models.Task) error {
+	query := `INSERT INTO tasks (id, owner_id, title, description, due_date, done, created_at, updated_at) 
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	_, err := r.db.ExecContext(ctx, query,
+		task.ID, task.OwnerID, task.Title, task.Description, task.DueDate, task.Done, task.CreatedAt, task.UpdatedAt)
+	return err
+}
+
+func (r *PostgresTaskRepository) GetByID(ctx context.Context, ownerID, id string) (*models.Task, error) {
+	query := `SELECT ` + taskColumns + ` FROM tasks
+              WHERE id = $1 AND owner_id = $2 AND deleted_at IS NULL`
+	task, err := scanTask(r.db.QueryRowContext(ctx, query, id, ownerID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+func (r *PostgresTaskRepository) List(ctx context.Context, ownerID string) ([]*models.Task, error) {
+	query := `SELECT ` + taskColumns + ` FROM tasks
+              WHERE owner_id = $1 AND deleted_at IS NULL ORDER BY created_at DESC`
+	rows, err := r.db.QueryContext(ctx, query, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	return scanTasks(rows)
+}
+
 func (r *PostgresTaskRepository) Update(ctx context.Context, task *models.Task) error {
-	query := `UPDATE tasks SET title = $1, description = $2, done = $3, updated_at = NOW() WHERE id = $4`
-	result, err := r.db.ExecContext(ctx, query, task.Title, task.Description, task.Done, task.ID)
+	query := `UPDATE tasks SET title = $1, description = $2, due_date = $3, done = $4, updated_at = NOW()
+              WHERE id = $5 AND owner_id = $6 AND deleted_at IS NULL`
+	result, err := r.db.ExecContext(ctx, query,
+		task.Title, task.Description, task.DueDate, task.Done, task.ID, task.OwnerID)
 	if err != nil {
 		return err
 	}
@@ -86,9 +121,9 @@ func (r *PostgresTaskRepository) Update(ctx context.Context, task *models.Task)
 	return nil
 }
 
-func (r *PostgresTaskRepository) Delete(ctx context.Context, id string) error {
-	query := `DELETE FROM tasks WHERE id = $1`
-	result, err := r.db.ExecContext(ctx, query, id)
+func (r *PostgresTaskRepository) Delete(ctx context.Context, ownerID, id string) error {
+	query := `UPDATE tasks SET deleted_at = NOW() WHERE id = $1 AND owner_id = $2 AND deleted_at IS NULL`
+	result, err := r.db.ExecContext(ctx, query, id, ownerID)
 	if err != nil {
 		return err
 	}
@@ -103,45 +138,61 @@ func (r *PostgresTaskRepository) Delete(ctx context.Context, id string) error {
 }
 
 // SearchByTitleUnsafe - УЯЗВИМАЯ ВЕРСИЯ для демонстрации SQL-инъекции
-func (r *PostgresTaskRepository) SearchByTitleUnsafe(ctx context.Context, titleSubstring string) ([]*models.Task, error) {
+func (r *PostgresTaskRepository) SearchByTitleUnsafe(ctx context.Context, ownerID, titleSubstring string) ([]*models.Task, error) {
 	// ВНИМАНИЕ: ЭТОТ КОД УЯЗВИМ ДЛЯ SQL-ИНЪЕКЦИЙ! ТОЛЬКО ДЛЯ ДЕМОНСТРАЦИИ!
-	query := fmt.Sprintf("SELECT id, title, description, done, created_at, updated_at FROM tasks WHERE title LIKE '%%%s%%'", titleSubstring)
+	// Инъекция в title обходит и фильтр по владельцу
+	query := fmt.Sprintf("SELECT %s FROM tasks WHERE owner_id = $1 AND deleted_at IS NULL AND title LIKE '%%%s%%'", taskColumns, titleSubstring)
 
-	rows, err := r.db.QueryContext(ctx, query)
+	rows, err := r.db.QueryContext(ctx, query, ownerID)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	return scanTasks(rows)
+}
 
-	var tasks []*models.Task
-	for rows.Next() {
-		task := &models.Task{}
-		err := rows.Scan(&task.ID, &task.Title, &task.Description, &task.Done, &task.CreatedAt, &task.UpdatedAt)
-		if err != nil {
-			return nil, err
-		}
-		tasks = append(tasks, task)
+// SearchByTitle - БЕЗОПАСНАЯ ВЕРСИЯ с параметризованным запросом
+func (r *PostgresTaskRepository) SearchByTitle(ctx context.Context, ownerID, titleSubstring string) ([]*models.Task, error) {
+	query := `SELECT ` + taskColumns + ` FROM tasks
+              WHERE owner_id = $1 AND deleted_at IS NULL AND title ILIKE $2`
+	rows, err := r.db.QueryContext(ctx, query, ownerID, "%"+titleSubstring+"%")
+	if err != nil {
+		return nil, err
 	}
-	return tasks, rows.Err()
+	return scanTasks(rows)
 }
 
-// SearchByTitle - БЕЗОПАСНАЯ ВЕРСИЯ с параметризованным запросом
-func (r *PostgresTaskRepository) SearchByTitle(ctx context.Context, titleSubstring string) ([]*models.Task, error) {
-	query := `SELECT id, title, description, done, created_at, updated_at FROM tasks WHERE title ILIKE $1`
-	rows, err := r.db.QueryContext(ctx, query, "%"+titleSubstring+"%")
+// ListAll возвращает все задачи, включая удалённые (для администраторов)
+func (r *PostgresTaskRepository) ListAll(ctx context.Context) ([]*models.Task, error) {
+	query := `SELECT ` + taskColumns + ` FROM tasks ORDER BY created_at DESC`
+	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	return scanTasks(rows)
+}
 
-	var tasks []*models.Task
-	for rows.Next() {
-		task := &models.Task{}
-		err := rows.Scan(&task.ID, &task.Title, &task.Description, &task.Done, &task.CreatedAt, &task.UpdatedAt)
-		if err != nil {
-			return nil, err
-		}
-		tasks = append(tasks, task)
+// HardDelete удаляет задачу безвозвратно (для администраторов)
+func (r *PostgresTaskRepository) HardDelete(ctx context.Context, id string) error {
+	query := `DELETE FROM tasks WHERE id = $1`
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
 	}
-	return tasks, rows.Err()
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// PurgeDeleted безвозвратно удаляет задачи, помеченные удалёнными раньше before
+func (r *PostgresTaskRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM tasks WHERE deleted_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
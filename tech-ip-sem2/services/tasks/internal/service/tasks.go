@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"strings"
 	"time"
 
@@ -23,6 +24,27 @@ func sanitizeInput(input string) string {
 	return replacer.Replace(input)
 }
 
+// ErrInvalidDueDate - срок выполнения не в формате RFC 3339 или YYYY-MM-DD
+var ErrInvalidDueDate = errors.New("invalid due_date: expected RFC 3339 or YYYY-MM-DD")
+
+// dateLayout - срок без времени, трактуется как начало суток по UTC
+const dateLayout = "2006-01-02"
+
+// parseDueDate разбирает срок выполнения; пустая строка - срок не задан
+func parseDueDate(value string) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.RFC3339, dateLayout} {
+		if t, err := time.Parse(layout, value); err == nil {
+			t = t.UTC()
+			return &t, nil
+		}
+	}
+	return nil, ErrInvalidDueDate
+}
+
 type TaskService struct {
 	repo repository.TaskRepository
 }
@@ -33,16 +55,23 @@ func NewTaskService(repo repository.TaskRepository) *TaskService {
 	}
 }
 
-func (s *TaskService) Create(ctx context.Context, title, description, dueDate string) (*models.Task, error) {
+// Create создаёт задачу владельца owner. dueDate - RFC 3339 или YYYY-MM-DD, пустая строка - без срока.
+func (s *TaskService) Create(ctx context.Context, owner, title, description, dueDate string) (*models.Task, error) {
+	due, err := parseDueDate(dueDate)
+	if err != nil {
+		return nil, err
+	}
+
 	// Санитизация входных данных
 	title = sanitizeInput(title)
 	description = sanitizeInput(description)
 
 	task := &models.Task{
 		ID:          "t_" + uuid.New().String(),
+		OwnerID:     owner,
 		Title:       title,
 		Description: description,
-		DueDate:     dueDate,
+		DueDate:     due,
 		Done:        false,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
@@ -54,8 +83,9 @@ func (s *TaskService) Create(ctx context.Context, title, description, dueDate st
 	return task, nil
 }
 
-func (s *TaskService) GetByID(ctx context.Context, id string) (*models.Task, error) {
-	task, err := s.repo.GetByID(ctx, id)
+// GetByID возвращает задачу владельца; чужая или неизвестная задача - sql.ErrNoRows
+func (s *TaskService) GetByID(ctx context.Context, owner, id string) (*models.Task, error) {
+	task, err := s.repo.GetByID(ctx, owner, id)
 	if err != nil {
 		return nil, err
 	}
@@ -65,13 +95,22 @@ func (s *TaskService) GetByID(ctx context.Context, id string) (*models.Task, err
 	return task, nil
 }
 
-func (s *TaskService) List(ctx context.Context) ([]*models.Task, error) {
-	return s.repo.List(ctx)
+func (s *TaskService) List(ctx context.Context, owner string) ([]*models.Task, error) {
+	return s.repo.List(ctx, owner)
 }
 
-func (s *TaskService) Update(ctx context.Context, id string, title, description, dueDate *string, done *bool) (*models.Task, error) {
+// Update изменяет переданные (не nil) поля. Пустой dueDate снимает срок выполнения.
+func (s *TaskService) Update(ctx context.Context, owner, id string, title, description, dueDate *string, done *bool) (*models.Task, error) {
+	var due *time.Time
+	if dueDate != nil {
+		var err error
+		if due, err = parseDueDate(*dueDate); err != nil {
+			return nil, err
+		}
+	}
+
 	// Сначала получаем существующую задачу
-	existing, err := s.repo.GetByID(ctx, id)
+	existing, err := s.repo.GetByID(ctx, owner, id)
 	if err != nil {
 		return nil, err
 	}
@@ -87,7 +126,7 @@ func (s *TaskService) Update(ctx context.Context, id string, title, description,
 		existing.Description = sanitizeInput(*description)
 	}
 	if dueDate != nil {
-		existing.DueDate = *dueDate // дату не санитируем
+		existing.DueDate = due
 	}
 	if done != nil {
 		existing.Done = *done
@@ -101,18 +140,33 @@ func (s *TaskService) Update(ctx context.Context, id string, title, description,
 	return existing, nil
 }
 
-func (s *TaskService) Delete(ctx context.Context, id string) error {
-	return s.repo.Delete(ctx, id)
+func (s *TaskService) Delete(ctx context.Context, owner, id string) error {
+	return s.repo.Delete(ctx, owner, id)
+}
+
+// ListAll возвращает задачи всех пользователей, включая удалённые (для администраторов)
+func (s *TaskService) ListAll(ctx context.Context) ([]*models.Task, error) {
+	return s.repo.ListAll(ctx)
+}
+
+// HardDelete удаляет задачу безвозвратно (для администраторов)
+func (s *TaskService) HardDelete(ctx context.Context, id string) error {
+	return s.repo.HardDelete(ctx, id)
+}
+
+// PurgeDeleted окончательно удаляет задачи, удалённые пользователями дольше retention назад
+func (s *TaskService) PurgeDeleted(ctx context.Context, retention time.Duration) (int64, error) {
+	return s.repo.PurgeDeleted(ctx, time.Now().Add(-retention))
 }
 
-func (s *TaskService) SearchByTitle(ctx context.Context, query string, unsafe bool) ([]*models.Task, error) {
+func (s *TaskService) SearchByTitle(ctx context.Context, owner, query string, unsafe bool) ([]*models.Task, error) {
 	if unsafe {
 		// В реальном коде так делать нельзя! Только для демонстрации SQL-инъекции
 		if postgresRepo, ok := s.repo.(*repository.PostgresTaskRepository); ok {
-			return postgresRepo.SearchByTitleUnsafe(ctx, query)
+			return postgresRepo.SearchByTitleUnsafe(ctx, owner, query)
 		}
 	}
 	// Санитизация поискового запроса (хотя параметризованный запрос уже безопасен)
 	query = sanitizeInput(query)
-	return s.repo.SearchByTitle(ctx, query)
+	return s.repo.SearchByTitle(ctx, owner, query)
 }
@@ -0,0 +1,24 @@
+package csrf
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// Token возвращает CSRF-токен, привязанный к сессии: base64url(HMAC-SHA256(secret, sessionID)).
+// Новый session ID при каждом логине автоматически даёт новый токен.
+func Token(secret []byte, sessionID string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(sessionID))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Valid проверяет, что токен выдан для этой сессии. Сравнение выполняется за постоянное время.
+func Valid(secret []byte, sessionID, token string) bool {
+	if sessionID == "" || token == "" {
+		return false
+	}
+	expected := Token(secret, sessionID)
+	return hmac.Equal([]byte(expected), []byte(token))
+}
@@ -0,0 +1,100 @@
+// Package health - проверки живости и готовности сервиса (/healthz, /readyz)
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// readinessTimeout ограничивает время одной проверки готовности
+const readinessTimeout = 2 * time.Second
+
+type healthCheck struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+type healthResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// Health отвечает на /healthz (процесс жив) и /readyz (готов принимать трафик).
+// Готовность снимается при остановке сервера и при отказе любой из зависимостей.
+type Health struct {
+	logger   *logrus.Logger
+	stopping atomic.Bool
+
+	mu     sync.RWMutex
+	checks []healthCheck
+}
+
+func New(logger *logrus.Logger) *Health {
+	return &Health{logger: logger}
+}
+
+// AddCheck добавляет проверку зависимости (например, ping базы данных)
+func (h *Health) AddCheck(name string, check func(ctx context.Context) error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks = append(h.checks, healthCheck{name: name, check: check})
+}
+
+// SetStopping снимает готовность перед остановкой, чтобы балансировщик перестал слать запросы
+func (h *Health) SetStopping() {
+	h.stopping.Store(true)
+}
+
+// Ready выполняет проверки и возвращает их результаты
+func (h *Health) Ready(ctx context.Context) (map[string]string, bool) {
+	h.mu.RLock()
+	checks := h.checks
+	h.mu.RUnlock()
+
+	results := make(map[string]string, len(checks))
+	ready := !h.stopping.Load()
+	for _, c := range checks {
+		cctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+		err := c.check(cctx)
+		cancel()
+		if err != nil {
+			h.logger.WithError(err).WithField("check", c.name).Warn("readiness check failed")
+			results[c.name] = "fail"
+			ready = false
+			continue
+		}
+		results[c.name] = "ok"
+	}
+	return results, ready
+}
+
+// Healthz обрабатывает GET /healthz (liveness): отвечает, пока процесс обслуживает запросы
+func (h *Health) Healthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, healthResponse{Status: "ok"})
+}
+
+// Readyz обрабатывает GET /readyz (readiness)
+func (h *Health) Readyz(w http.ResponseWriter, r *http.Request) {
+	checks, ready := h.Ready(r.Context())
+	if h.stopping.Load() {
+		writeJSON(w, http.StatusServiceUnavailable, healthResponse{Status: "stopping", Checks: checks})
+		return
+	}
+	if !ready {
+		writeJSON(w, http.StatusServiceUnavailable, healthResponse{Status: "not ready", Checks: checks})
+		return
+	}
+	writeJSON(w, http.StatusOK, healthResponse{Status: "ready", Checks: checks})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,40 @@
+// Package interceptors - общие gRPC-перехватчики сервера: request-id, журнал вызовов,
+// метрики Prometheus и восстановление после panic.
+package interceptors
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+)
+
+// ServerOptions возвращает цепочку перехватчиков в правильном порядке:
+// request-id -> журнал -> метрики -> recovery -> обработчик.
+// Recovery стоит ближе всех к обработчику, чтобы журнал и метрики видели codes.Internal после panic.
+func ServerOptions(logger *logrus.Logger) []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(
+			UnaryRequestID(),
+			UnaryLogging(logger),
+			UnaryMetrics(),
+			UnaryRecovery(logger),
+		),
+		grpc.ChainStreamInterceptor(
+			StreamRequestID(),
+			StreamLogging(logger),
+			StreamMetrics(),
+			StreamRecovery(logger),
+		),
+	}
+}
+
+// serverStream подменяет контекст потока (grpc.ServerStream не позволяет сделать это иначе)
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
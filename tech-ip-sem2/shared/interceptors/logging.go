@@ -0,0 +1,55 @@
+package interceptors
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/shared/middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryLogging пишет структурированную запись о каждом вызове
+func UnaryLogging(logger *logrus.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logCall(ctx, logger, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// StreamLogging пишет запись по завершении потока
+func StreamLogging(logger *logrus.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		logCall(ss.Context(), logger, info.FullMethod, start, err)
+		return err
+	}
+}
+
+func logCall(ctx context.Context, logger *logrus.Logger, method string, start time.Time, err error) {
+	code := status.Code(err)
+	fields := logrus.Fields{
+		"component":   "grpc_server",
+		"method":      method,
+		"code":        code.String(),
+		"duration_ms": time.Since(start).Milliseconds(),
+		"request_id":  middleware.GetRequestID(ctx),
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		fields["peer"] = p.Addr.String()
+	}
+	entry := logger.WithFields(fields)
+
+	switch code {
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unimplemented:
+		entry.WithError(err).Error("rpc completed")
+	default:
+		entry.Info("rpc completed")
+	}
+}
@@ -0,0 +1,80 @@
+package interceptors
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	// Счётчик завершённых вызовов по методу и коду ответа
+	rpcHandled = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "grpc_server_handled_total",
+			Help: "Total number of RPCs completed on the server",
+		},
+		[]string{"method", "code"},
+	)
+
+	// Гистограмма длительности вызовов
+	rpcDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "grpc_server_handling_seconds",
+			Help:    "Duration of RPCs handled by the server in seconds",
+			Buckets: []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1},
+		},
+		[]string{"method"},
+	)
+
+	// Текущее количество выполняющихся вызовов
+	rpcInFlight = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "grpc_server_in_flight_requests",
+			Help: "Current number of in-flight RPCs",
+		},
+	)
+
+	// Паники в обработчиках, перехваченные UnaryRecovery/StreamRecovery
+	rpcPanics = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "grpc_server_panics_total",
+			Help: "Total number of panics recovered in RPC handlers",
+		},
+		[]string{"method"},
+	)
+)
+
+// UnaryMetrics собирает метрики вызовов
+func UnaryMetrics() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		rpcInFlight.Inc()
+		defer rpcInFlight.Dec()
+
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		observe(info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// StreamMetrics собирает метрики потоковых вызовов (длительность - всё время жизни потока)
+func StreamMetrics() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		rpcInFlight.Inc()
+		defer rpcInFlight.Dec()
+
+		start := time.Now()
+		err := handler(srv, ss)
+		observe(info.FullMethod, start, err)
+		return err
+	}
+}
+
+func observe(method string, start time.Time, err error) {
+	rpcHandled.WithLabelValues(method, status.Code(err).String()).Inc()
+	rpcDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
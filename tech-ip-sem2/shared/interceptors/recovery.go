@@ -0,0 +1,49 @@
+package interceptors
+
+import (
+	"context"
+	"runtime/debug"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sun1tar/MIREA-TIP-Practice-22/tech-ip-sem2/shared/middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryRecovery превращает panic в обработчике в codes.Internal, не роняя процесс
+func UnaryRecovery(logger *logrus.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(ctx, logger, info.FullMethod, r)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// StreamRecovery - то же для потоковых вызовов
+func StreamRecovery(logger *logrus.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(ss.Context(), logger, info.FullMethod, r)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+func recovered(ctx context.Context, logger *logrus.Logger, method string, r any) error {
+	rpcPanics.WithLabelValues(method).Inc()
+	logger.WithFields(logrus.Fields{
+		"component":  "grpc_server",
+		"method":     method,
+		"request_id": middleware.GetRequestID(ctx),
+		"panic":      r,
+		"stack":      string(debug.Stack()),
+	}).Error("panic in rpc handler")
+	// Подробности остаются в логе, клиенту - только код
+	return status.Error(codes.Internal, "internal error")
+}
@@ -0,0 +1,76 @@
+package jwks
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Claims - содержимое access-токена, выпускаемого auth service
+type Claims struct {
+	jwt.RegisteredClaims
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"` // разрешения, которые дают роли
+	Scope       string   `json:"scope,omitempty"`       // scopes токена через пробел (RFC 9068)
+}
+
+// Scopes возвращает scopes токена списком
+func (c *Claims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+// Key - открытый ключ в формате JWK (RFC 7517). Поддерживаются только Ed25519 (RFC 8037).
+type Key struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Kid string `json:"kid"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+}
+
+// Set - JWKS документ, публикуемый по /.well-known/jwks.json
+type Set struct {
+	Keys []Key `json:"keys"`
+}
+
+// FromEd25519 формирует JWK для открытого ключа Ed25519
+func FromEd25519(kid string, pub ed25519.PublicKey) Key {
+	return Key{
+		Kty: "OKP",
+		Crv: "Ed25519",
+		X:   base64.RawURLEncoding.EncodeToString(pub),
+		Kid: kid,
+		Use: "sig",
+		Alg: jwt.SigningMethodEdDSA.Alg(),
+	}
+}
+
+// PublicKey декодирует ключ Ed25519 из JWK
+func (k Key) PublicKey() (ed25519.PublicKey, error) {
+	if k.Kty != "OKP" || k.Crv != "Ed25519" {
+		return nil, fmt.Errorf("unsupported key type %s/%s", k.Kty, k.Crv)
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key encoding: %w", err)
+	}
+	if len(raw) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid Ed25519 key size")
+	}
+	return ed25519.PublicKey(raw), nil
+}
+
+// Lookup ищет ключ по kid
+func (s Set) Lookup(kid string) (Key, bool) {
+	for _, k := range s.Keys {
+		if k.Kid == kid {
+			return k, true
+		}
+	}
+	return Key{}, false
+}
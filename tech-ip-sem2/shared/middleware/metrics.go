@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Определяем метрики
+var (
+	// Счётчик запросов по методу, пути и статусу
+	requestsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Total number of HTTP requests",
+		},
+		[]string{"method", "route", "status"},
+	)
+
+	// Гистограмма длительности запросов
+	requestDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Duration of HTTP requests in seconds",
+			Buckets: []float64{0.01, 0.05, 0.1, 0.3, 1, 3}, // учебные бакеты
+		},
+		[]string{"method", "route"},
+	)
+
+	// Текущее количество активных запросов
+	inFlightRequests = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "http_in_flight_requests",
+			Help: "Current number of in-flight HTTP requests",
+		},
+	)
+)
+
+// MetricsMiddleware собирает метрики для каждого HTTP запроса. Метка route - шаблон маршрута
+// из routes ("/v1/tasks/{id}"), поэтому число меток ограничено числом маршрутов.
+func MetricsMiddleware(routes *http.ServeMux) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Увеличиваем счётчик активных запросов
+			inFlightRequests.Inc()
+			defer inFlightRequests.Dec()
+
+			route := matchedRoute(routes, r)
+			method := r.Method
+
+			// Засекаем время начала
+			start := time.Now()
+
+			// Оборачиваем ResponseWriter для захвата статуса
+			wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+
+			// Выполняем следующий обработчик
+			next.ServeHTTP(wrapped, r)
+
+			// Вычисляем длительность
+			duration := time.Since(start).Seconds()
+
+			// Сохраняем метрики
+			status := strconv.Itoa(wrapped.statusCode)
+			requestsTotal.WithLabelValues(method, route, status).Inc()
+			requestDuration.WithLabelValues(method, route).Observe(duration)
+		})
+	}
+}
+
+// unmatchedRoute - метка запросов, не совпавших ни с одним маршрутом (404, 405):
+// произвольные пути сканеров не должны порождать новые временные ряды
+const unmatchedRoute = "other"
+
+// matchedRoute возвращает путь из шаблона маршрута, который обработает запрос
+// (метод в шаблоне опускается - он уже есть в метке method)
+func matchedRoute(routes *http.ServeMux, r *http.Request) string {
+	_, pattern := routes.Handler(r)
+	if pattern == "" {
+		return unmatchedRoute
+	}
+	if _, path, ok := strings.Cut(pattern, " "); ok {
+		return path
+	}
+	return pattern
+}
+
+// MetricsHandler возвращает HTTP handler для /metrics
+func MetricsHandler() http.Handler {
+	return promhttp.Handler()
+}
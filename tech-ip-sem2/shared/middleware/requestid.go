@@ -17,12 +17,17 @@ func RequestIDMiddleware(next http.Handler) http.Handler {
 		if requestID == "" {
 			requestID = uuid.New().String()
 		}
-		ctx := context.WithValue(r.Context(), RequestIDKey, requestID)
+		ctx := WithRequestID(r.Context(), requestID)
 		w.Header().Set("X-Request-ID", requestID)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// WithRequestID сохраняет request-id в контексте (для вызовов вне HTTP, например gRPC)
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, RequestIDKey, requestID)
+}
+
 func GetRequestID(ctx context.Context) string {
 	if val, ok := ctx.Value(RequestIDKey).(string); ok {
 		return val
@@ -0,0 +1,184 @@
+// Package server - общий запуск и остановка сервисов: HTTP и gRPC серверы с таймаутами,
+// обработка SIGINT/SIGTERM и корректное завершение (drain) в пределах дедлайна.
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+)
+
+// DefaultShutdownTimeout - сколько по умолчанию ждать завершения текущих запросов
+const DefaultShutdownTimeout = 15 * time.Second
+
+// Таймауты HTTP-сервера: медленный или зависший клиент не держит соединение бесконечно
+const (
+	readHeaderTimeout = 5 * time.Second
+	readTimeout       = 15 * time.Second
+	writeTimeout      = 30 * time.Second // долгие выгрузки снимают дедлайн через http.ResponseController
+	idleTimeout       = 120 * time.Second
+)
+
+// NewHTTPServer создаёт HTTP-сервер с таймаутами чтения, записи и простоя
+func NewHTTPServer(addr string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       idleTimeout,
+	}
+}
+
+type httpServer struct {
+	name string
+	srv  *http.Server
+}
+
+type grpcServer struct {
+	name string
+	srv  *grpc.Server
+	lis  net.Listener
+}
+
+type closer struct {
+	name string
+	fn   func() error
+}
+
+// Runner запускает серверы и останавливает их по сигналу или при падении одного из них.
+// Порядок остановки: хуки OnStop (снять готовность), drain всех серверов не дольше
+// shutdownTimeout, затем освобождение ресурсов (Close) в обратном порядке регистрации.
+type Runner struct {
+	logger          *logrus.Logger
+	shutdownTimeout time.Duration
+
+	https   []httpServer
+	grpcs   []grpcServer
+	onStop  []func()
+	closers []closer
+}
+
+func New(logger *logrus.Logger, shutdownTimeout time.Duration) *Runner {
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = DefaultShutdownTimeout
+	}
+	return &Runner{logger: logger, shutdownTimeout: shutdownTimeout}
+}
+
+// HTTP регистрирует HTTP-сервер (см. NewHTTPServer)
+func (r *Runner) HTTP(name string, srv *http.Server) {
+	r.https = append(r.https, httpServer{name: name, srv: srv})
+}
+
+// GRPC регистрирует gRPC-сервер и его listener
+func (r *Runner) GRPC(name string, srv *grpc.Server, lis net.Listener) {
+	r.grpcs = append(r.grpcs, grpcServer{name: name, srv: srv, lis: lis})
+}
+
+// OnStop добавляет действие, выполняемое первым при остановке (например, снять готовность)
+func (r *Runner) OnStop(fn func()) {
+	r.onStop = append(r.onStop, fn)
+}
+
+// Close добавляет освобождение ресурса (БД, клиентские соединения) после остановки серверов
+func (r *Runner) Close(name string, fn func() error) {
+	r.closers = append(r.closers, closer{name: name, fn: fn})
+}
+
+// Run запускает серверы и блокируется до SIGINT/SIGTERM или ошибки сервера,
+// после чего останавливает сервис. Возвращает ошибку сервера, если остановка вызвана ею.
+func (r *Runner) Run() error {
+	errCh := make(chan error, len(r.https)+len(r.grpcs))
+	for _, s := range r.https {
+		go func(s httpServer) {
+			r.logger.WithFields(logrus.Fields{"server": s.name, "addr": s.srv.Addr}).Info("HTTP server starting")
+			if err := s.srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				r.logger.WithError(err).WithField("server", s.name).Error("HTTP server failed")
+				errCh <- err
+			}
+		}(s)
+	}
+	for _, s := range r.grpcs {
+		go func(s grpcServer) {
+			r.logger.WithFields(logrus.Fields{"server": s.name, "addr": s.lis.Addr().String()}).Info("gRPC server starting")
+			if err := s.srv.Serve(s.lis); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+				r.logger.WithError(err).WithField("server", s.name).Error("gRPC server failed")
+				errCh <- err
+			}
+		}(s)
+	}
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(quit)
+
+	var runErr error
+	select {
+	case sig := <-quit:
+		r.logger.WithField("signal", sig.String()).Info("shutting down")
+	case runErr = <-errCh:
+		r.logger.Warn("shutting down after server failure")
+	}
+
+	r.shutdown()
+	return runErr
+}
+
+func (r *Runner) shutdown() {
+	for _, fn := range r.onStop {
+		fn()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.shutdownTimeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, s := range r.https {
+		wg.Add(1)
+		go func(s httpServer) {
+			defer wg.Done()
+			if err := s.srv.Shutdown(ctx); err != nil {
+				r.logger.WithError(err).WithField("server", s.name).Warn("HTTP drain timed out, closing connections")
+				s.srv.Close()
+			}
+		}(s)
+	}
+	for _, s := range r.grpcs {
+		wg.Add(1)
+		go func(s grpcServer) {
+			defer wg.Done()
+			// Долгие стримы не дают GracefulStop завершиться - по дедлайну рвём соединения
+			stopped := make(chan struct{})
+			go func() {
+				s.srv.GracefulStop()
+				close(stopped)
+			}()
+			select {
+			case <-stopped:
+			case <-ctx.Done():
+				r.logger.WithField("server", s.name).Warn("gRPC drain timed out, closing connections")
+				s.srv.Stop()
+			}
+		}(s)
+	}
+	wg.Wait()
+
+	for i := len(r.closers) - 1; i >= 0; i-- {
+		c := r.closers[i]
+		if err := c.fn(); err != nil {
+			r.logger.WithError(err).WithField("resource", c.name).Warn("failed to close")
+		}
+	}
+	r.logger.Info("shutdown complete")
+}
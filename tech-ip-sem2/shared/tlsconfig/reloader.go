@@ -0,0 +1,144 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// checkInterval - как часто (не чаще) проверять, изменились ли файлы на диске
+const checkInterval = 5 * time.Second
+
+// Files - пути к PEM-файлам. Пустые поля не используются.
+type Files struct {
+	CertFile string // сертификат (цепочка) этой стороны
+	KeyFile  string // приватный ключ к CertFile
+	CAFile   string // CA bundle для проверки другой стороны
+}
+
+// Reloader держит в памяти сертификат и CA bundle и перечитывает их при изменении файлов,
+// поэтому сертификаты можно обновить без перезапуска сервиса. Проверка выполняется лениво,
+// при очередном TLS-рукопожатии. Если новые файлы не читаются, продолжает работать старый сертификат.
+type Reloader struct {
+	files  Files
+	logger *logrus.Logger
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	pool      *x509.CertPool
+	stamp     string // размеры и время изменения файлов при последней загрузке
+	checkedAt time.Time
+}
+
+// NewReloader загружает файлы; ошибка, если их нельзя прочитать при старте
+func NewReloader(files Files, logger *logrus.Logger) (*Reloader, error) {
+	if (files.CertFile == "") != (files.KeyFile == "") {
+		return nil, errors.New("tls: certificate and key must be set together")
+	}
+	r := &Reloader{files: files, logger: logger}
+	stamp, err := r.stampFiles()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.load(stamp); err != nil {
+		return nil, err
+	}
+	r.checkedAt = time.Now()
+	return r, nil
+}
+
+// Certificate возвращает текущий сертификат (nil, если не задан)
+func (r *Reloader) Certificate() *tls.Certificate {
+	r.maybeReload()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert
+}
+
+// CAPool возвращает текущий CA bundle (nil, если не задан - используются системные корни)
+func (r *Reloader) CAPool() *x509.CertPool {
+	r.maybeReload()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.pool
+}
+
+func (r *Reloader) maybeReload() {
+	r.mu.Lock()
+	if time.Since(r.checkedAt) < checkInterval {
+		r.mu.Unlock()
+		return
+	}
+	r.checkedAt = time.Now()
+	current := r.stamp
+	r.mu.Unlock()
+
+	stamp, err := r.stampFiles()
+	if err != nil {
+		r.logger.WithError(err).Warn("tls: cannot stat certificate files, keeping current ones")
+		return
+	}
+	if stamp == current {
+		return
+	}
+	if err := r.load(stamp); err != nil {
+		// Файлы могут быть записаны не полностью - попробуем снова при следующей проверке
+		r.logger.WithError(err).Warn("tls: failed to reload certificates, keeping current ones")
+		return
+	}
+	r.logger.WithFields(logrus.Fields{
+		"cert": r.files.CertFile,
+		"ca":   r.files.CAFile,
+	}).Info("tls: certificates reloaded")
+}
+
+func (r *Reloader) load(stamp string) error {
+	var cert *tls.Certificate
+	if r.files.CertFile != "" {
+		c, err := tls.LoadX509KeyPair(r.files.CertFile, r.files.KeyFile)
+		if err != nil {
+			return fmt.Errorf("tls: load key pair: %w", err)
+		}
+		cert = &c
+	}
+
+	var pool *x509.CertPool
+	if r.files.CAFile != "" {
+		pem, err := os.ReadFile(r.files.CAFile)
+		if err != nil {
+			return fmt.Errorf("tls: read CA bundle: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("tls: no certificates found in %s", r.files.CAFile)
+		}
+	}
+
+	r.mu.Lock()
+	r.cert = cert
+	r.pool = pool
+	r.stamp = stamp
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *Reloader) stampFiles() (string, error) {
+	var stamp string
+	for _, path := range []string{r.files.CertFile, r.files.KeyFile, r.files.CAFile} {
+		if path == "" {
+			continue
+		}
+		fi, err := os.Stat(path)
+		if err != nil {
+			return "", err
+		}
+		stamp += fmt.Sprintf("%s:%d:%d;", path, fi.Size(), fi.ModTime().UnixNano())
+	}
+	return stamp, nil
+}
@@ -0,0 +1,84 @@
+// Package tlsconfig собирает *tls.Config для gRPC между сервисами:
+// серверный TLS с необязательной проверкой клиентских сертификатов (mTLS)
+// и клиентский TLS с собственным CA bundle. Сертификаты перечитываются с диска
+// при изменении (см. Reloader).
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+)
+
+// Server возвращает конфигурацию сервера. Если requireClientCert, клиент обязан
+// предъявить сертификат, подписанный CA из files.CAFile (mTLS).
+func Server(r *Reloader, requireClientCert bool) (*tls.Config, error) {
+	if r.files.CertFile == "" {
+		return nil, errors.New("tls: server certificate is required")
+	}
+	if requireClientCert && r.files.CAFile == "" {
+		return nil, errors.New("tls: client CA bundle is required for mutual TLS")
+	}
+
+	base := &tls.Config{MinVersion: tls.VersionTLS12}
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// Конфигурация собирается на каждое подключение, чтобы подхватить перечитанные файлы
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cfg := base.Clone()
+			cfg.Certificates = []tls.Certificate{*r.Certificate()}
+			if requireClientCert {
+				cfg.ClientAuth = tls.RequireAndVerifyClientCert
+				cfg.ClientCAs = r.CAPool()
+			}
+			return cfg, nil
+		},
+	}, nil
+}
+
+// Client возвращает конфигурацию клиента. serverName - имя (или IP), которое должно
+// быть в сертификате сервера; обычно это хост из адреса подключения.
+// Если в files задан сертификат, он предъявляется серверу (mTLS).
+func Client(r *Reloader, serverName string) (*tls.Config, error) {
+	if serverName == "" {
+		return nil, errors.New("tls: server name is required")
+	}
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: serverName,
+	}
+	if r.files.CertFile != "" {
+		cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return r.Certificate(), nil
+		}
+	}
+	if r.files.CAFile != "" {
+		// RootCAs фиксируется при создании конфигурации, поэтому цепочку сервера проверяем
+		// сами по текущему bundle. Стандартная проверка отключена только ради этого:
+		// VerifyConnection выполняет её полностью, включая имя сервера.
+		cfg.InsecureSkipVerify = true
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			return verifyServer(cs, serverName, r.CAPool())
+		}
+	}
+	return cfg, nil
+}
+
+// verifyServer проверяет цепочку и имя сервера. Имя берётся из настроек, а не из
+// ConnectionState.ServerName: для IP-адресов SNI не отправляется и поле пустое.
+func verifyServer(cs tls.ConnectionState, serverName string, roots *x509.CertPool) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("tls: server did not present a certificate")
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range cs.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := cs.PeerCertificates[0].Verify(x509.VerifyOptions{
+		DNSName:       serverName,
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	return err
+}